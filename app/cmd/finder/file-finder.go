@@ -1,9 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -21,9 +25,9 @@ import (
 // #region Cli Setup
 var (
 	rootCmd = &cobra.Command{
-		Use:   "file-finder [root-directory]",
+		Use:   "file-finder [root-directory...]",
 		Short: "Find files of specified size and type",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MinimumNArgs(1),
 		Run:   run,
 	}
 
@@ -66,6 +70,14 @@ func registerFloat64Flag(cmd *cobra.Command, name, shorthand string, value float
 	cmd.Flags().Float64VarP(target, name, shorthand, value, usage+"\n")
 }
 
+func registerStringSliceFlag(cmd *cobra.Command, name, shorthand string, value []string, usage string, target *[]string) {
+	cmd.Flags().StringSliceVarP(target, name, shorthand, value, usage+"\n")
+}
+
+func registerIntFlag(cmd *cobra.Command, name, shorthand string, value int, usage string, target *int) {
+	cmd.Flags().IntVarP(target, name, shorthand, value, usage+"\n")
+}
+
 func newCompletionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
@@ -86,19 +98,412 @@ func newCompletionCmd() *cobra.Command {
 	}
 }
 
+func newPurgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge [quarantine-directory]",
+		Short: "Permanently delete quarantined files older than a given age",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			olderThanValue, _ := cmd.Flags().GetString("older-than")
+			olderThan, err := utils.ParseAge(olderThanValue)
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			if err := utils.PurgeQuarantine(args[0], olderThan); err != nil {
+				pterm.Error.Printf("error purging quarantine: %v\n", err)
+			}
+		},
+	}
+
+	registerStringFlag(cmd, "older-than", "", "30d", "Only purge files quarantined longer ago than this (30d, 2w, 12h)", new(string), nil)
+
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore [quarantine-directory]",
+		Short: "Move quarantined files back to their original location",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := utils.RestoreQuarantine(args[0]); err != nil {
+				pterm.Error.Printf("error restoring quarantine: %v\n", err)
+			}
+		},
+	}
+}
+
+func newIndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index [root-directory...]",
+		Short: "Store the files under the root directories in an index for --use-index, only rescanning what changed",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			indexFile, _ := cmd.Flags().GetString("index-file")
+			hashValue, _ := cmd.Flags().GetString("hash")
+			full, _ := cmd.Flags().GetBool("full")
+
+			var hashAlgorithm types.HashAlgorithm
+			if hashValue != "" {
+				if hashAlgorithm = utils.ToHashAlgorithm(hashValue); hashAlgorithm == "" {
+					pterm.Error.Printf("invalid hash: %s", hashValue)
+					return
+				}
+			}
+
+			if err := utils.BuildIndex(args, indexFile, hashAlgorithm, full); err != nil {
+				pterm.Error.Printf("error building index: %v\n", err)
+			}
+		},
+	}
+
+	registerStringFlag(cmd, "index-file", "", utils.DefaultIndexPath(), "Index file to write", new(string), nil)
+	registerStringFlag(cmd, "hash", "", "", "Also store a hash of every file, reused by --use-index while the file is unchanged, --hash=<algorithm> picks one of crc32, fnv64, md5, sha1, sha256", new(string), nil)
+	cmd.Flags().Lookup("hash").NoOptDefVal = string(types.HashAlgorithms.SHA256)
+	registerBoolFlag(cmd, "full", "", false, "Rescan every directory and rehash every file instead of reusing the previous index", new(bool))
+
+	return cmd
+}
+
+// registerFilterFlags registers the flags that decide which files match, they
+// are shared by every command that scans the root directories
+func registerFilterFlags(cmd *cobra.Command) {
+	registerFloat64Flag(cmd, "tolerance-size", "l", 0.05, "File size tolerance", &options.ToleranceSize)
+	registerStringFlag(cmd, "file-name-filter", "f", "", "Name to filter results by", &options.FileNameFilter, nil)
+	registerStringFlag(cmd, "file-size-filter", "s", "", "File size to search for (1 KB, 1 MB, 1 GB)", &options.FileSizeFilter, nil)
+	registerStringFlag(cmd, "file-type-filter", "t", string(commonTypes.FileTypes.Any), "File type to search for (Any, Archive, Documents, Image, Video)", &options.FileTypeFilter, nil)
+	registerStringFlag(cmd, "newer-than", "", "", "Only find files modified within this age (30d, 2w, 12h)", new(string), nil)
+	registerStringFlag(cmd, "older-than", "", "", "Only find files modified longer ago than this age (30d, 2w, 12h)", new(string), nil)
+	registerStringFlag(cmd, "operator-type", "o", string(commonTypes.OperatorTypes.EqualTo), "Operator to apply on file size\n(EqualTo: 'et', 'equal to', 'equal', '==')\n(GreaterThan: 'gt','greater', 'greater than', '>')\n(GreaterThanEqualTo: 'gte', 'greater than or equal to', 'greaterthanorequalto', '>=')\n(LessThan: 'lt', 'less', 'less than', 'lessthan', '<')\n(LessThanEqualTo: 'lte', 'less than or equal to',  'lessthanorequalto', '<='))", &options.OperatorTypeFilter, nil)
+}
+
+// registerHashFlag registers the flag choosing the hash used to compare file contents
+func registerHashFlag(cmd *cobra.Command) {
+	registerStringFlag(cmd, "hash", "", string(types.HashAlgorithms.SHA256), "Hash used to compare file contents (crc32, fnv64, md5, sha1, sha256), crc32 and fnv64 are fastest but can collide, so duplicates are confirmed with sha256 before they are acted on", new(string), nil)
+}
+
+func newWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [root-directory...]",
+		Short: "Report files that start or stop matching the filters as they change, optionally acting on new matches",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Only the flags of the command being run are bound so they read the same as the root command's
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args)
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			actionType, actionDirectory, err := getActionFlags()
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			collisionStrategy := utils.ToCollisionStrategy(viper.GetString("on-collision"))
+			if collisionStrategy == "" {
+				pterm.Error.Printf("invalid collision strategy: %s", viper.GetString("on-collision"))
+				return
+			}
+
+			if actionType != "" && viper.GetString("exec") != "" {
+				pterm.Error.Printf("The flag --%s-to cannot be used together with --exec (-x)", strings.ToLower(string(actionType)))
+				return
+			}
+
+			fileFinder.ActionDirectory = actionDirectory
+			fileFinder.ActionType = actionType
+			fileFinder.CollisionStrategy = collisionStrategy
+			fileFinder.ExecCommand = viper.GetString("exec")
+			fileFinder.ExecJobs = viper.GetInt("exec-jobs")
+			fileFinder.FlattenDirectories = viper.GetBool("flatten")
+
+			// Already validated by getFilterOptions
+			newerThan, olderThan, _ := getAgeFilters()
+
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			if err := utils.WatchFiles(fileFinder, newerThan, olderThan, jsonOutput); err != nil {
+				pterm.Error.Printf("error watching files: %v\n", err)
+			}
+		},
+	}
+
+	registerFilterFlags(cmd)
+	registerBoolFlag(cmd, "json", "", false, "Print one JSON object per line for each change instead of text", new(bool))
+	registerStringFlag(cmd, "move-to", "", "", "Move newly matching files into this directory", new(string), nil)
+	registerStringFlag(cmd, "copy-to", "", "", "Copy newly matching files into this directory", new(string), nil)
+	registerStringFlag(cmd, "link-to", "", "", "Symlink newly matching files into this directory", new(string), nil)
+	registerBoolFlag(cmd, "flatten", "", false, "Place moved, copied or linked files directly in the target directory instead of preserving their relative path", new(bool))
+	registerStringFlag(cmd, "on-collision", "", string(types.CollisionStrategies.Skip), "What to do when a move, copy or link destination exists (skip, overwrite, rename)", new(string), nil)
+	registerStringFlag(cmd, "exec", "x", "", "Command to run for each newly matching file, placeholders: {} or {path}, {dir}, {base}, {ext}", new(string), nil)
+	registerIntFlag(cmd, "exec-jobs", "", runtime.NumCPU(), "Maximum number of commands run at the same time, changes keep being reported while they run", new(int))
+
+	return cmd
+}
+
+func newSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save scans to files and compare them to see what changed",
+	}
+
+	saveCmd := &cobra.Command{
+		Use:   "save [snapshot-file] [root-directory...]",
+		Short: "Save the paths, sizes, modification times and hashes of the matching files",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args[1:])
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			if fileFinder.HashAlgorithm, err = getHashAlgorithm(); err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			if err := utils.SaveSnapshot(fileFinder, args[0]); err != nil {
+				pterm.Error.Printf("error saving snapshot: %v\n", err)
+			}
+		},
+	}
+	registerFilterFlags(saveCmd)
+	registerHashFlag(saveCmd)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [snapshot-file] [snapshot-file]",
+		Short: "List the files added, removed, modified, grown or moved between two snapshots",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			if err := utils.DiffSnapshots(args[0], args[1], jsonOutput); err != nil {
+				pterm.Error.Printf("error comparing snapshots: %v\n", err)
+			}
+		},
+	}
+	registerBoolFlag(diffCmd, "json", "", false, "Print the changes as JSON instead of a table", new(bool))
+
+	cmd.AddCommand(saveCmd)
+	cmd.AddCommand(diffCmd)
+
+	return cmd
+}
+
+func newCompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare [directory-a] [directory-b]",
+		Short: "List the matching files only in one directory or differing between them",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args)
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			if fileFinder.HashAlgorithm, err = getHashAlgorithm(); err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			compareContent, _ := cmd.Flags().GetBool("content")
+			syncList, _ := cmd.Flags().GetString("sync-list")
+			if err := utils.CompareDirectories(fileFinder, args[0], args[1], compareContent, syncList); err != nil {
+				pterm.Error.Printf("error comparing directories: %v\n", err)
+			}
+		},
+	}
+
+	registerFilterFlags(cmd)
+	registerHashFlag(cmd)
+	registerBoolFlag(cmd, "content", "", false, "Compare the hashes of files with the same size instead of their modification times", new(bool))
+	registerStringFlag(cmd, "sync-list", "", "", "Write the paths of the files to copy from A to make B match it to this file", new(string), nil)
+
+	return cmd
+}
+
+func newChecksumCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checksum",
+		Short: "Write sha256sum style checksum manifests and verify files against them",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create [manifest-file] [root-directory...]",
+		Short: "Write the checksums of the matching files to a manifest",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args[1:])
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			if fileFinder.HashAlgorithm, err = getHashAlgorithm(); err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			format := utils.ToChecksumFormat(viper.GetString("format"))
+			if format == "" {
+				pterm.Error.Printf("invalid checksum format: %s", viper.GetString("format"))
+				return
+			}
+
+			if err := utils.WriteChecksums(fileFinder, args[0], format); err != nil {
+				pterm.Error.Printf("error writing checksums: %v\n", err)
+			}
+		},
+	}
+	registerFilterFlags(createCmd)
+	registerHashFlag(createCmd)
+	registerStringFlag(createCmd, "format", "", string(types.ChecksumFormats.GNU), "Manifest line format (gnu: 'hash  path', bsd: 'SHA256 (path) = hash')", new(string), nil)
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify [manifest-file]",
+		Short: "Rehash the files listed in a manifest and report the ones that changed or are missing",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := utils.VerifyChecksums(args[0]); err != nil {
+				pterm.Error.Printf("error verifying checksums: %v\n", err)
+				// Scripts and cron jobs rely on the exit code to notice changed files
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.AddCommand(createCmd)
+	cmd.AddCommand(verifyCmd)
+
+	return cmd
+}
+
+func newUsageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage [root-directory...]",
+		Short: "Show how much space the matching files take up per directory, largest first",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args)
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			depth, _ := cmd.Flags().GetInt("depth")
+			if depth < 0 {
+				pterm.Error.Printf("invalid depth: %d, expected 0 or more", depth)
+				return
+			}
+
+			if err := utils.DisplayUsage(fileFinder, depth); err != nil {
+				pterm.Error.Printf("error summarizing usage: %v\n", err)
+			}
+		},
+	}
+
+	registerFilterFlags(cmd)
+	registerIntFlag(cmd, "depth", "", 1, "Number of directory levels below the root directories to list, every directory counts the files of its subdirectories like du", new(int))
+
+	return cmd
+}
+
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats [root-directory...]",
+		Short: "Break the matching files down by extension, file type, size and age",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			fileFinder, err := getFilterOptions(args)
+			if err != nil {
+				pterm.Error.Println(err)
+				return
+			}
+
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			if err := utils.DisplayStats(fileFinder, jsonOutput); err != nil {
+				pterm.Error.Printf("error collecting stats: %v\n", err)
+			}
+		},
+	}
+
+	registerFilterFlags(cmd)
+	registerBoolFlag(cmd, "json", "", false, "Print the stats as JSON instead of tables", new(bool))
+
+	return cmd
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	registerBoolFlag(rootCmd, "display-app-banner", "b", false, "Whether or not to display the application banner", &options.DisplayApplicationBanner)
 	registerBoolFlag(rootCmd, "display-detailed-results", "d", false, "Display detailed results", &options.DisplayDetailedResults)
 	registerBoolFlag(rootCmd, "list-duplicate-files", "u", false, "Lists duplicate files", &options.ListDuplicateFiles)
+	registerStringFlag(rootCmd, "keep", "k", "", "Which file of each duplicate set to keep, the rest are marked for removal\n(oldest, newest, shortest-path, longest-path, preferred, first)", &options.KeepPolicy, nil)
+	registerBoolFlag(rootCmd, "cross-root", "", false, "Only list duplicate sets with files under more than one root directory", &options.CrossRootDuplicates)
+	registerStringFlag(rootCmd, "unique-to", "", "", "List files under this root directory that have no copy under any other root directory", &options.UniqueToRoot, nil)
+	registerStringFlag(rootCmd, "output", "", string(types.OutputFormats.Table), "How found files are rendered (table, tree, html, markdown)", &options.OutputFormat, nil)
+	registerStringFlag(rootCmd, "report-file", "", "file-finder-report.html", "File the --output html report is written to", &options.ReportFile, nil)
+	registerStringFlag(rootCmd, "format", "", "", "Go template printed for each found file in place of the table, like '{{.Path}}\\t{{.FileSize}}'\n(fields: Root, Directory, FileName, Path, Extension, Size, FileSize, ModTime, Count)", new(string), nil)
+	registerStringFlag(rootCmd, "template", "", "", "Name of a template in --template-dir to print for each found file, like --format", new(string), nil)
+	registerStringFlag(rootCmd, "template-dir", "", utils.DefaultTemplateDirectory(), "Directory --template names are loaded from, as <name>.tmpl", new(string), nil)
+	registerStringFlag(rootCmd, "sort-by", "", string(types.SortFields.Path), "What found files are ordered by (path, name, size, count, mtime, extension)", &options.SortBy, nil)
+	registerBoolFlag(rootCmd, "reverse", "", false, "Reverse the order of --sort-by", &options.SortReverse)
+	registerStringFlag(rootCmd, "group-by", "", "", "Group the detailed results by directory, extension or type", &options.GroupBy, nil)
+	registerIntFlag(rootCmd, "tree-depth", "", 0, "Collapse directories more than this many levels below the root directories in --output tree, 0 expands all", &options.TreeDepth)
+	registerIntFlag(rootCmd, "top", "", 0, "Only list the N largest matching files and the N largest directories", &options.TopCount)
+	registerBoolFlag(rootCmd, "similar-images", "", false, "List groups of visually similar JPEG, PNG and GIF images", &options.SimilarImages)
+	registerIntFlag(rootCmd, "similarity-distance", "", 10, "Maximum number of differing hash bits (0-64) for images to be considered similar", &options.SimilarityDistance)
+	registerStringFlag(rootCmd, "image-hash", "", string(types.ImageHashAlgorithms.Difference), "Perceptual hash used for --similar-images (ahash, dhash)", &options.ImageHashAlgorithm, nil)
+	registerBoolFlag(rootCmd, "similar-names", "", false, "List groups of files with similar names, ignoring case, trailing copy markers, separators and extensions, names only differing in numbers or their first letter are kept apart", &options.SimilarNames)
+	registerFloat64Flag(rootCmd, "name-similarity", "", 0.8, "Minimum similarity (0-1) for names to be grouped by --similar-names", &options.NameSimilarity)
+	registerHashFlag(rootCmd)
+	registerBoolFlag(rootCmd, "hardlink-duplicates", "", false, "Replace duplicates marked for removal with hard links to the kept file", &options.HardlinkDuplicates)
+	registerStringSliceFlag(rootCmd, "prefer-prefix", "", nil, "Path prefixes in order of preference for --keep preferred", &options.PreferredPrefixes)
+	registerFilterFlags(rootCmd)
 	registerBoolFlag(rootCmd, "remove-files", "r", false, "Remove found files", &options.RemoveFiles)
-	registerFloat64Flag(rootCmd, "tolerance-size", "l", 0.05, "File size tolerance", &options.ToleranceSize)
-	registerStringFlag(rootCmd, "file-name-filter", "f", "", "Name to filter results by", &options.FileNameFilter, nil)
-	registerStringFlag(rootCmd, "file-size-filter", "s", "", "File size to search for (1 KB, 1 MB, 1 GB)", &options.FileSizeFilter, nil)
-	registerStringFlag(rootCmd, "file-type-filter", "t", string(commonTypes.FileTypes.Any), "File type to search for (Any, Archive, Documents, Image, Video)", &options.FileTypeFilter, nil)
-	registerStringFlag(rootCmd, "operator-type", "o", string(commonTypes.OperatorTypes.EqualTo), "Operator to apply on file size\n(EqualTo: 'et', 'equal to', 'equal', '==')\n(GreaterThan: 'gt','greater', 'greater than', '>')\n(GreaterThanEqualTo: 'gte', 'greater than or equal to', 'greaterthanorequalto', '>=')\n(LessThan: 'lt', 'less', 'less than', 'lessthan', '<')\n(LessThanEqualTo: 'lte', 'less than or equal to',  'lessthanorequalto', '<='))", &options.OperatorTypeFilter, nil)
+	registerBoolFlag(rootCmd, "interactive-select", "i", false, "Choose which found files to act on before removing, moving or running commands", &options.InteractiveSelect)
+	registerBoolFlag(rootCmd, "use-index", "", false, "Answer the query from the index built by the index command instead of scanning", &options.UseIndex)
+	registerStringFlag(rootCmd, "index-file", "", utils.DefaultIndexPath(), "Index file used by --use-index", &options.IndexFile, nil)
+	registerStringFlag(rootCmd, "quarantine-dir", "q", "", "Move found files into this directory instead of deleting them", &options.QuarantineDirectory, nil)
+	registerStringFlag(rootCmd, "move-to", "", "", "Move found files into this directory", new(string), nil)
+	registerStringFlag(rootCmd, "copy-to", "", "", "Copy found files into this directory", new(string), nil)
+	registerStringFlag(rootCmd, "link-to", "", "", "Symlink found files into this directory", new(string), nil)
+	registerBoolFlag(rootCmd, "flatten", "", false, "Place moved, copied or linked files directly in the target directory instead of preserving their relative path", &options.FlattenDirectories)
+	registerStringFlag(rootCmd, "on-collision", "", string(types.CollisionStrategies.Skip), "What to do when a move, copy or link destination exists (skip, overwrite, rename)", &options.CollisionStrategy, nil)
+	registerStringFlag(rootCmd, "exec", "x", "", "Command to run for each found file, placeholders: {} or {path}, {dir}, {base}, {ext}", &options.ExecCommand, nil)
+	registerStringFlag(rootCmd, "exec-batch", "", "", "Command to run with the found files in place of {}+ (appended when missing), more than 512 files are split into batches run one after another", &options.ExecBatchCommand, nil)
+	registerIntFlag(rootCmd, "exec-jobs", "", runtime.NumCPU(), "Maximum number of --exec commands run at the same time", &options.ExecJobs)
+	// Every subcommand renders tables, so the pager can be turned off for all of them
+	rootCmd.PersistentFlags().Bool("no-pager", false, "Print output taller than the terminal directly instead of through $PAGER or the built-in pager\n (default false)")
+	viper.BindPFlag("no-pager", rootCmd.PersistentFlags().Lookup("no-pager"))
+
 	rootCmd.AddCommand(newCompletionCmd())
+	rootCmd.AddCommand(newPurgeCmd())
+	rootCmd.AddCommand(newRestoreCmd())
+	rootCmd.AddCommand(newIndexCmd())
+	rootCmd.AddCommand(newWatchCmd())
+	rootCmd.AddCommand(newSnapshotCmd())
+	rootCmd.AddCommand(newCompareCmd())
+	rootCmd.AddCommand(newChecksumCmd())
+	rootCmd.AddCommand(newUsageCmd())
+	rootCmd.AddCommand(newStatsCmd())
 
 	viper.BindPFlags(rootCmd.Flags())
 }
@@ -106,23 +511,153 @@ func init() {
 func initConfig() {
 	viper.SetEnvPrefix("FF")
 	viper.AutomaticEnv()
+
+	if viper.GetBool("no-pager") {
+		utils.DisablePager()
+	}
 }
 
 func run(cmd *cobra.Command, args []string) {
 
-	fileTypeFilter := commonUtils.ToFileType(string(viper.GetString("file-type-filter")))
-	operatorType := commonUtils.ToOperatorType(string(viper.GetString("operator-type")))
+	fileFinder, err := getFilterOptions(args)
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+	fileTypeFilter := fileFinder.FileTypeFilter
+
+	if fileFinder.HashAlgorithm, err = getHashAlgorithm(); err != nil {
+		pterm.Error.Println(err)
+		return
+	}
 
 	removeFiles := viper.GetBool("remove-files")
-	displayDetailedResults := viper.GetBool("display-detailed-results")
+	quarantineDirectory := viper.GetString("quarantine-dir")
+	hardlinkDuplicates := viper.GetBool("hardlink-duplicates")
+	crossRootDuplicates := viper.GetBool("cross-root")
+	listDuplicateFiles := viper.GetBool("list-duplicate-files") || hardlinkDuplicates || crossRootDuplicates
 
-	if fileTypeFilter == "" {
-		pterm.Error.Printf("invalid file type: %s", viper.GetString("file-type-filter"))
+	uniqueToRoot, err := getUniqueToRoot(args)
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+	if uniqueToRoot != "" && listDuplicateFiles {
+		pterm.Error.Printf("The flag --unique-to cannot be used together with duplicate listing")
 		return
 	}
 
-	if operatorType == "" {
-		pterm.Error.Printf("invalid operator type: %s", viper.GetString("operator-type"))
+	similarImages := viper.GetBool("similar-images")
+	imageHashAlgorithm := utils.ToImageHashAlgorithm(viper.GetString("image-hash"))
+	if imageHashAlgorithm == "" {
+		pterm.Error.Printf("invalid image hash: %s", viper.GetString("image-hash"))
+		return
+	}
+
+	similarityDistance := viper.GetInt("similarity-distance")
+	if similarityDistance < 0 || similarityDistance > 64 {
+		pterm.Error.Printf("invalid similarity distance: %d, expected a value between 0 and 64", similarityDistance)
+		return
+	}
+
+	if similarImages && fileTypeFilter != commonTypes.FileTypes.Image && fileTypeFilter != commonTypes.FileTypes.Any {
+		pterm.Error.Printf("The flag --similar-images only works with the Image or Any file type")
+		return
+	}
+
+	if similarImages && (listDuplicateFiles || uniqueToRoot != "") {
+		pterm.Error.Printf("The flag --similar-images cannot be used together with duplicate listing or --unique-to")
+		return
+	}
+
+	similarNames := viper.GetBool("similar-names")
+	nameSimilarity := viper.GetFloat64("name-similarity")
+	if nameSimilarity < 0 || nameSimilarity > 1 {
+		pterm.Error.Printf("invalid name similarity: %v, expected a value between 0 and 1", nameSimilarity)
+		return
+	}
+
+	if similarNames && (listDuplicateFiles || uniqueToRoot != "" || similarImages) {
+		pterm.Error.Printf("The flag --similar-names cannot be used together with duplicate listing, --unique-to or --similar-images")
+		return
+	}
+
+	outputFormat := utils.ToOutputFormat(viper.GetString("output"))
+	if outputFormat == "" {
+		pterm.Error.Printf("invalid output format: %s", viper.GetString("output"))
+		return
+	}
+
+	if outputFormat != types.OutputFormats.Table && (similarImages || similarNames || viper.GetInt("top") > 0) {
+		pterm.Error.Printf("The flag --output %s cannot be used together with --similar-images, --similar-names or --top", outputFormat)
+		return
+	}
+
+	if (outputFormat == types.OutputFormats.Tree || outputFormat == types.OutputFormats.Markdown) && listDuplicateFiles {
+		pterm.Error.Printf("The flag --output %s cannot be used together with duplicate listing", outputFormat)
+		return
+	}
+
+	if viper.GetString("format") != "" && viper.GetString("template") != "" {
+		pterm.Error.Printf("The flags --format and --template cannot be used together")
+		return
+	}
+
+	formatTemplate, err := utils.LoadTemplate(viper.GetString("format"), viper.GetString("template"), viper.GetString("template-dir"))
+	if err != nil {
+		pterm.Error.Printf("invalid template: %v", err)
+		return
+	}
+
+	if formatTemplate != "" && (outputFormat != types.OutputFormats.Table || listDuplicateFiles || similarImages || similarNames || viper.GetInt("top") > 0) {
+		pterm.Error.Printf("The flags --format and --template cannot be used together with --output, duplicate listing, --similar-images, --similar-names or --top")
+		return
+	}
+
+	treeDepth := viper.GetInt("tree-depth")
+	if treeDepth < 0 {
+		pterm.Error.Printf("invalid tree depth: %d, expected 0 or more", treeDepth)
+		return
+	}
+
+	// Duplicates are found by comparing individual files so they always need
+	// detailed results, the tree needs them for the file names and sizes
+	displayDetailedResults := viper.GetBool("display-detailed-results") || listDuplicateFiles || uniqueToRoot != "" || viper.GetBool("similar-images") || viper.GetBool("similar-names") || outputFormat == types.OutputFormats.Tree
+
+	sortBy := utils.ToSortField(viper.GetString("sort-by"))
+	if sortBy == "" {
+		pterm.Error.Printf("invalid sort field: %s", viper.GetString("sort-by"))
+		return
+	}
+
+	sortReverse := viper.GetBool("reverse")
+	if (sortBy != types.SortFields.Path || sortReverse) && (listDuplicateFiles || similarImages || similarNames || viper.GetInt("top") > 0) {
+		pterm.Error.Printf("The flags --sort-by and --reverse cannot be used together with duplicate listing, --similar-images, --similar-names or --top")
+		return
+	}
+
+	// Directory summaries only have a path, a name and a file count
+	if !displayDetailedResults && sortBy != types.SortFields.Path && sortBy != types.SortFields.Name && sortBy != types.SortFields.Count {
+		pterm.Error.Printf("The flags --sort-by %s and --display-detailed-results (-d) must be used together, any other combination isn't supported", sortBy)
+		return
+	}
+
+	var groupBy types.GroupField
+	if viper.GetString("group-by") != "" {
+		groupBy = utils.ToGroupField(viper.GetString("group-by"))
+		if groupBy == "" {
+			pterm.Error.Printf("invalid group field: %s", viper.GetString("group-by"))
+			return
+		}
+	}
+
+	if groupBy != "" && !displayDetailedResults {
+		pterm.Error.Printf("The flags --group-by and --display-detailed-results (-d) must be used together, any other combination isn't supported")
+		return
+	}
+
+	if groupBy != "" && outputFormat == types.OutputFormats.Tree {
+		pterm.Error.Printf("The flag --group-by cannot be used together with --output tree")
 		return
 	}
 
@@ -131,28 +666,276 @@ func run(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	fileFinder := types.FileFinder{
-		DisplayApplicationBanner: viper.GetBool("display-app-banner"),
-		DisplayDetailedResults:   viper.GetBool("display-detailed-results"),
-		FileNameFilter:           viper.GetString("file-name-filter"),
-		FileSizeFilter:           viper.GetString("file-size-filter"),
-		FileTypeFilter:           fileTypeFilter,
-		ListDuplicateFiles:       viper.GetBool("list-duplicate-files"),
-		RemoveFiles:              removeFiles,
-		ToleranceSize:            viper.GetFloat64("tolerance-size"),
-		OperatorTypeFilter:       operatorType,
-		Results:                  make(map[string][]string),
-		RootDirectory:            args[0],
+	if quarantineDirectory != "" && !displayDetailedResults {
+		pterm.Error.Printf("The flags --quarantine-dir (-q) and --display-detailed-results (-d) must be used together, any other combination isn't supported")
+		return
+	}
+
+	if quarantineDirectory != "" && removeFiles {
+		pterm.Error.Printf("The flags --quarantine-dir (-q) and --remove-files (-r) cannot be used together")
+		return
+	}
+
+	actionType, actionDirectory, err := getActionFlags()
+	if err != nil {
+		pterm.Error.Println(err)
+		return
 	}
 
+	collisionStrategy := utils.ToCollisionStrategy(viper.GetString("on-collision"))
+	if collisionStrategy == "" {
+		pterm.Error.Printf("invalid collision strategy: %s", viper.GetString("on-collision"))
+		return
+	}
+
+	if actionType != "" && !displayDetailedResults {
+		pterm.Error.Printf("The flag --%s-to and --display-detailed-results (-d) must be used together, any other combination isn't supported", strings.ToLower(string(actionType)))
+		return
+	}
+
+	if actionType != "" && (removeFiles || quarantineDirectory != "") {
+		pterm.Error.Printf("The flag --%s-to cannot be used together with --remove-files (-r) or --quarantine-dir (-q)", strings.ToLower(string(actionType)))
+		return
+	}
+
+	execCommand := viper.GetString("exec")
+	execBatchCommand := viper.GetString("exec-batch")
+
+	if execCommand != "" && execBatchCommand != "" {
+		pterm.Error.Printf("The flags --exec (-x) and --exec-batch cannot be used together")
+		return
+	}
+
+	if (execCommand != "" || execBatchCommand != "") && !displayDetailedResults {
+		pterm.Error.Printf("The flags --exec (-x) or --exec-batch and --display-detailed-results (-d) must be used together, any other combination isn't supported")
+		return
+	}
+
+	var keepPolicy types.KeepPolicy
+	if viper.GetString("keep") != "" {
+		keepPolicy = utils.ToKeepPolicy(viper.GetString("keep"))
+		if keepPolicy == "" {
+			pterm.Error.Printf("invalid keep policy: %s", viper.GetString("keep"))
+			return
+		}
+	}
+
+	if hardlinkDuplicates && keepPolicy == "" {
+		keepPolicy = types.KeepPolicies.First
+	}
+
+	preferredPrefixes := viper.GetStringSlice("prefer-prefix")
+
+	if keepPolicy == types.KeepPolicies.Preferred && len(preferredPrefixes) == 0 {
+		pterm.Error.Printf("The keep policy preferred requires --prefer-prefix")
+		return
+	}
+
+	if keepPolicy != "" && !listDuplicateFiles {
+		pterm.Error.Printf("The flags --keep (-k) and --list-duplicate-files (-u) must be used together")
+		return
+	}
+
+	if listDuplicateFiles && keepPolicy == "" && (removeFiles || quarantineDirectory != "" || actionType == types.ActionTypes.Move) {
+		pterm.Error.Printf("Removing or moving duplicates requires --keep (-k) so one file of each set is kept")
+		return
+	}
+
+	interactiveSelect := viper.GetBool("interactive-select")
+	if interactiveSelect && !displayDetailedResults {
+		pterm.Error.Printf("The flags --interactive-select (-i) and --display-detailed-results (-d) must be used together, any other combination isn't supported")
+		return
+	}
+
+	if (similarImages || similarNames) && !interactiveSelect && (removeFiles || quarantineDirectory != "" || actionType == types.ActionTypes.Move) {
+		pterm.Error.Printf("Removing or moving similar images or names requires --interactive-select (-i) to choose which files to act on")
+		return
+	}
+
+	if hardlinkDuplicates && (removeFiles || quarantineDirectory != "" || actionType != "" || execCommand != "" || execBatchCommand != "" || interactiveSelect) {
+		pterm.Error.Printf("The flag --hardlink-duplicates cannot be combined with other actions or --interactive-select (-i)")
+		return
+	}
+
+	topCount := viper.GetInt("top")
+	if topCount < 0 {
+		pterm.Error.Printf("invalid top count: %d, expected a positive number", topCount)
+		return
+	}
+
+	if topCount > 0 && (listDuplicateFiles || uniqueToRoot != "" || similarImages || similarNames || viper.GetBool("use-index") || removeFiles || quarantineDirectory != "" || actionType != "" || execCommand != "" || execBatchCommand != "" || interactiveSelect) {
+		pterm.Error.Printf("The flag --top cannot be combined with other listing modes, --use-index or actions")
+		return
+	}
+
+	fileFinder.ActionDirectory = actionDirectory
+	fileFinder.ActionType = actionType
+	fileFinder.CollisionStrategy = collisionStrategy
+	fileFinder.CrossRootDuplicates = crossRootDuplicates
+	fileFinder.DisplayApplicationBanner = viper.GetBool("display-app-banner")
+	fileFinder.DisplayDetailedResults = displayDetailedResults
+	fileFinder.ExecBatchCommand = execBatchCommand
+	fileFinder.ExecCommand = execCommand
+	fileFinder.ExecJobs = viper.GetInt("exec-jobs")
+	fileFinder.FlattenDirectories = viper.GetBool("flatten")
+	fileFinder.GroupBy = groupBy
+	fileFinder.IndexFile = viper.GetString("index-file")
+	fileFinder.HardlinkDuplicates = hardlinkDuplicates
+	fileFinder.ImageHashAlgorithm = imageHashAlgorithm
+	fileFinder.InteractiveSelect = interactiveSelect
+	fileFinder.KeepPolicy = keepPolicy
+	fileFinder.ListDuplicateFiles = listDuplicateFiles
+	fileFinder.NameSimilarity = nameSimilarity
+	fileFinder.OutputFormat = outputFormat
+	fileFinder.RemoveFiles = removeFiles
+	fileFinder.ReportFile = viper.GetString("report-file")
+	fileFinder.UniqueToRoot = uniqueToRoot
+	fileFinder.UseIndex = viper.GetBool("use-index")
+	fileFinder.PreferredPrefixes = preferredPrefixes
+	fileFinder.QuarantineDirectory = quarantineDirectory
+	fileFinder.SimilarImages = similarImages
+	fileFinder.SimilarNames = similarNames
+	fileFinder.SimilarityDistance = similarityDistance
+	fileFinder.SortBy = sortBy
+	fileFinder.SortReverse = sortReverse
+	fileFinder.Template = formatTemplate
+	fileFinder.TopCount = topCount
+	fileFinder.TreeDepth = treeDepth
+
 	Run(fileFinder)
 }
 
+// getFilterOptions builds a FileFinder holding the root directories and the
+// validated filters shared by every command that scans them
+func getFilterOptions(roots []string) (types.FileFinder, error) {
+	fileTypeFilter := commonUtils.ToFileType(string(viper.GetString("file-type-filter")))
+	if fileTypeFilter == "" {
+		return types.FileFinder{}, fmt.Errorf("invalid file type: %s", viper.GetString("file-type-filter"))
+	}
+
+	operatorType := commonUtils.ToOperatorType(string(viper.GetString("operator-type")))
+	if operatorType == "" {
+		return types.FileFinder{}, fmt.Errorf("invalid operator type: %s", viper.GetString("operator-type"))
+	}
+
+	modifiedAfter, modifiedBefore, err := getModTimeFilters()
+	if err != nil {
+		return types.FileFinder{}, err
+	}
+
+	return types.FileFinder{
+		FileNameFilter:     viper.GetString("file-name-filter"),
+		FileSizeFilter:     viper.GetString("file-size-filter"),
+		FileTypeFilter:     fileTypeFilter,
+		ModifiedAfter:      modifiedAfter,
+		ModifiedBefore:     modifiedBefore,
+		OperatorTypeFilter: operatorType,
+		Results:            make(map[string][]string),
+		RootDirectories:    roots,
+		RootDirectory:      roots[0],
+		ToleranceSize:      viper.GetFloat64("tolerance-size"),
+	}, nil
+}
+
+// getHashAlgorithm returns the hash selected through --hash
+func getHashAlgorithm() (types.HashAlgorithm, error) {
+	hashAlgorithm := utils.ToHashAlgorithm(viper.GetString("hash"))
+	if hashAlgorithm == "" {
+		return "", fmt.Errorf("invalid hash: %s", viper.GetString("hash"))
+	}
+	return hashAlgorithm, nil
+}
+
+// getModTimeFilters converts --newer-than and --older-than into the
+// modification time bounds they describe
+func getModTimeFilters() (time.Time, time.Time, error) {
+	var modifiedAfter, modifiedBefore time.Time
+	newerThan, olderThan, err := getAgeFilters()
+	if err != nil {
+		return modifiedAfter, modifiedBefore, err
+	}
+
+	now := time.Now()
+	if newerThan > 0 {
+		modifiedAfter = now.Add(-newerThan)
+	}
+	if olderThan > 0 {
+		modifiedBefore = now.Add(-olderThan)
+	}
+	return modifiedAfter, modifiedBefore, nil
+}
+
+// getAgeFilters returns the ages given to --newer-than and --older-than, 0
+// when a flag is not set
+func getAgeFilters() (time.Duration, time.Duration, error) {
+	var newerThan, olderThan time.Duration
+	var err error
+
+	if value := viper.GetString("newer-than"); value != "" {
+		if newerThan, err = utils.ParseAge(value); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if value := viper.GetString("older-than"); value != "" {
+		if olderThan, err = utils.ParseAge(value); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return newerThan, olderThan, nil
+}
+
+// getUniqueToRoot returns the root directory given to --unique-to as it was
+// passed on the command line, so it matches the root results are tagged with
+func getUniqueToRoot(roots []string) (string, error) {
+	uniqueTo := viper.GetString("unique-to")
+	if uniqueTo == "" {
+		return "", nil
+	}
+
+	if len(roots) < 2 {
+		return "", fmt.Errorf("the flag --unique-to needs at least two root directories")
+	}
+
+	for _, root := range roots {
+		if filepath.Clean(root) == filepath.Clean(uniqueTo) {
+			return root, nil
+		}
+	}
+	return "", fmt.Errorf("the --unique-to directory %s is not one of the root directories", uniqueTo)
+}
+
+// getActionFlags returns the action selected through --move-to, --copy-to or --link-to
+func getActionFlags() (types.ActionType, string, error) {
+	var actionType types.ActionType
+	var actionDirectory string
+
+	for _, flag := range []string{"move-to", "copy-to", "link-to"} {
+		value := viper.GetString(flag)
+		if value == "" {
+			continue
+		}
+		if actionType != "" {
+			return "", "", fmt.Errorf("only one of --move-to, --copy-to or --link-to can be used at a time")
+		}
+		actionType = utils.ToActionType(strings.TrimSuffix(flag, "-to"))
+		actionDirectory = value
+	}
+
+	return actionType, actionDirectory, nil
+}
+
 // #endregion
 
 // #region Main Logic
 func main() {
-	commonCli.ClearTerminalScreen(runtime.GOOS)
+	if utils.IsTerminal() {
+		commonCli.ClearTerminalScreen(runtime.GOOS)
+	} else {
+		// Keep redirected output free of colours and escape sequences
+		pterm.DisableStyling()
+	}
 	if err := rootCmd.Execute(); err != nil {
 		return
 	}
@@ -167,9 +950,29 @@ func Run(ff types.FileFinder) {
 		return
 	}
 
+	if ff.InteractiveSelect {
+		files = utils.SelectFiles(files)
+	}
+
 	if ff.RemoveFiles {
 		utils.DeleteFiles(files)
 	}
+
+	if ff.QuarantineDirectory != "" {
+		utils.QuarantineFiles(files, ff)
+	}
+
+	if ff.ActionType != "" {
+		utils.ApplyAction(files, ff)
+	}
+
+	if ff.ExecCommand != "" || ff.ExecBatchCommand != "" {
+		utils.ExecuteCommands(files, ff)
+	}
+
+	if ff.HardlinkDuplicates {
+		utils.HardlinkDuplicates(files)
+	}
 }
 
 // #endregion
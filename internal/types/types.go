@@ -1,28 +1,329 @@
 package types
 
 import (
+	"os"
+	"time"
+
 	commonTypes "github.com/ondrovic/common/types"
 )
 
 type FileInfo struct {
-	Path string
-	Size int64
-	Hash string
+	Path          string        `json:"path"`
+	Size          int64         `json:"size"`
+	Hash          string        `json:"hash,omitempty"`
+	HashAlgorithm HashAlgorithm `json:"hashAlgorithm,omitempty"`
+	ModTime       time.Time     `json:"modTime"`
+	Mode          os.FileMode   `json:"mode,omitempty"`
+}
+
+// IndexedDirectory struct for the details stored about an indexed directory
+type IndexedDirectory struct {
+	Path           string
+	ModTime        time.Time
+	EntryCount     int
+	Subdirectories []string
 }
 
+// IndexStats struct for how much of a previous index was reused when re-indexing
+type IndexStats struct {
+	DirectoriesReused    int
+	DirectoriesRescanned int
+	FilesReused          int
+	FilesRescanned       int
+	HashesReused         int
+	HashesComputed       int
+}
+
+// IndexMeta struct for the details stored about an indexed root directory
+type IndexMeta struct {
+	Root          string
+	IndexedAt     time.Time
+	FileCount     int
+	HashAlgorithm HashAlgorithm
+}
+
+// ActionType is an operation applied to every found file
+type ActionType string
+
+// KeepPolicy decides which file of a duplicate set is kept
+type KeepPolicy string
+
+// DuplicateStatus marks whether a duplicate is kept or removed
+type DuplicateStatus string
+
+// HashAlgorithm is the hash used to compare and verify file contents
+type HashAlgorithm string
+
+// ImageHashAlgorithm is the perceptual hash used to compare images
+type ImageHashAlgorithm string
+
+// OutputFormat is how the found files are rendered
+type OutputFormat string
+
+// SortField is what the found files are ordered by
+type SortField string
+
+// GroupField is what the detailed results are grouped by
+type GroupField string
+
+// CollisionStrategy decides what happens when an action's destination already exists
+type CollisionStrategy string
+
+// ActionOutcome is the per-file result of an action
+type ActionOutcome string
+
+// ChecksumFormat is the line format of a checksum manifest
+type ChecksumFormat string
+
+// ChecksumStatus is the result of verifying a single file in a checksum manifest
+type ChecksumStatus string
+
+// CompareStatus is how a file differs between the two compared directories
+type CompareStatus string
+
+// SnapshotChangeType is the kind of change found between two snapshots
+type SnapshotChangeType string
+
+// WatchEventType is the kind of change reported by the watch command
+type WatchEventType string
+
+var (
+	// ActionTypes lists the supported actions
+	ActionTypes = struct {
+		Move     ActionType
+		Copy     ActionType
+		Link     ActionType
+		Hardlink ActionType
+	}{
+		Move:     "Move",
+		Copy:     "Copy",
+		Link:     "Link",
+		Hardlink: "Hardlink",
+	}
+
+	// CollisionStrategies lists the supported collision strategies
+	CollisionStrategies = struct {
+		Skip      CollisionStrategy
+		Overwrite CollisionStrategy
+		Rename    CollisionStrategy
+	}{
+		Skip:      "skip",
+		Overwrite: "overwrite",
+		Rename:    "rename",
+	}
+
+	// KeepPolicies lists the supported duplicate keep policies
+	KeepPolicies = struct {
+		Oldest       KeepPolicy
+		Newest       KeepPolicy
+		ShortestPath KeepPolicy
+		LongestPath  KeepPolicy
+		Preferred    KeepPolicy
+		First        KeepPolicy
+	}{
+		Oldest:       "oldest",
+		Newest:       "newest",
+		ShortestPath: "shortest-path",
+		LongestPath:  "longest-path",
+		Preferred:    "preferred",
+		First:        "first",
+	}
+
+	// HashAlgorithms lists the supported content hashes, CRC32 and FNV64 are
+	// fast but not collision resistant
+	HashAlgorithms = struct {
+		CRC32  HashAlgorithm
+		FNV64  HashAlgorithm
+		MD5    HashAlgorithm
+		SHA1   HashAlgorithm
+		SHA256 HashAlgorithm
+	}{
+		CRC32:  "crc32",
+		FNV64:  "fnv64",
+		MD5:    "md5",
+		SHA1:   "sha1",
+		SHA256: "sha256",
+	}
+
+	// ImageHashAlgorithms lists the supported perceptual hashes
+	ImageHashAlgorithms = struct {
+		Average    ImageHashAlgorithm
+		Difference ImageHashAlgorithm
+	}{
+		Average:    "ahash",
+		Difference: "dhash",
+	}
+
+	// DuplicateStatuses lists the statuses shown for duplicates
+	DuplicateStatuses = struct {
+		Keep   DuplicateStatus
+		Remove DuplicateStatus
+	}{
+		Keep:   "KEEP",
+		Remove: "REMOVE",
+	}
+
+	// ActionOutcomes lists the possible per-file results of an action
+	ActionOutcomes = struct {
+		Done    ActionOutcome
+		Skipped ActionOutcome
+		Failed  ActionOutcome
+	}{
+		Done:    "done",
+		Skipped: "skipped",
+		Failed:  "failed",
+	}
+
+	// ChecksumFormats lists the supported checksum manifest formats
+	ChecksumFormats = struct {
+		GNU ChecksumFormat
+		BSD ChecksumFormat
+	}{
+		GNU: "gnu",
+		BSD: "bsd",
+	}
+
+	// ChecksumStatuses lists the results of verifying a checksum manifest
+	ChecksumStatuses = struct {
+		OK      ChecksumStatus
+		Failed  ChecksumStatus
+		Missing ChecksumStatus
+	}{
+		OK:      "OK",
+		Failed:  "FAILED",
+		Missing: "MISSING",
+	}
+
+	// CompareStatuses lists the differences found by the compare command, in
+	// the order they are reported
+	CompareStatuses = struct {
+		OnlyInA        CompareStatus
+		OnlyInB        CompareStatus
+		SizeDiffers    CompareStatus
+		ModTimeDiffers CompareStatus
+		ContentDiffers CompareStatus
+	}{
+		OnlyInA:        "only in A",
+		OnlyInB:        "only in B",
+		SizeDiffers:    "size differs",
+		ModTimeDiffers: "modified time differs",
+		ContentDiffers: "content differs",
+	}
+
+	// SnapshotChangeTypes lists the changes found between two snapshots, in
+	// the order they are reported
+	SnapshotChangeTypes = struct {
+		Added    SnapshotChangeType
+		Removed  SnapshotChangeType
+		Modified SnapshotChangeType
+		Grown    SnapshotChangeType
+		Moved    SnapshotChangeType
+	}{
+		Added:    "added",
+		Removed:  "removed",
+		Modified: "modified",
+		Grown:    "grown",
+		Moved:    "moved",
+	}
+
+	// OutputFormats lists the supported renderings of the found files
+	OutputFormats = struct {
+		Table    OutputFormat
+		Tree     OutputFormat
+		HTML     OutputFormat
+		Markdown OutputFormat
+	}{
+		Table:    "table",
+		Tree:     "tree",
+		HTML:     "html",
+		Markdown: "markdown",
+	}
+
+	// SortFields lists the fields the found files can be sorted by
+	SortFields = struct {
+		Path      SortField
+		Name      SortField
+		Size      SortField
+		Count     SortField
+		ModTime   SortField
+		Extension SortField
+	}{
+		Path:      "path",
+		Name:      "name",
+		Size:      "size",
+		Count:     "count",
+		ModTime:   "mtime",
+		Extension: "extension",
+	}
+
+	// GroupFields lists the fields the detailed results can be grouped by
+	GroupFields = struct {
+		Directory GroupField
+		Extension GroupField
+		Type      GroupField
+	}{
+		Directory: "directory",
+		Extension: "extension",
+		Type:      "type",
+	}
+
+	// WatchEventTypes lists the changes reported by the watch command
+	WatchEventTypes = struct {
+		Matched   WatchEventType
+		Unmatched WatchEventType
+	}{
+		Matched:   "matched",
+		Unmatched: "unmatched",
+	}
+)
+
 // FileFinder struct remains the same
 type FileFinder struct {
+	ActionDirectory          string
+	ActionType               ActionType
+	CollisionStrategy        CollisionStrategy
+	CrossRootDuplicates      bool
 	DisplayApplicationBanner bool
 	DisplayDetailedResults   bool
+	ExecBatchCommand         string
+	ExecCommand              string
+	ExecJobs                 int
 	FileNameFilter           string
 	FileSizeFilter           string
 	FileTypeFilter           commonTypes.FileType
+	FlattenDirectories       bool
+	GroupBy                  GroupField
+	HardlinkDuplicates       bool
+	HashAlgorithm            HashAlgorithm
+	ImageHashAlgorithm       ImageHashAlgorithm
+	InteractiveSelect        bool
+	KeepPolicy               KeepPolicy
+	IndexFile                string
 	ListDuplicateFiles       bool
+	ModifiedAfter            time.Time
+	ModifiedBefore           time.Time
+	NameSimilarity           float64
 	OperatorTypeFilter       commonTypes.OperatorType
+	OutputFormat             OutputFormat
+	PreferredPrefixes        []string
+	QuarantineDirectory      string
 	RemoveFiles              bool
+	ReportFile               string
 	Results                  map[string][]string
+	RootDirectories          []string
 	RootDirectory            string
+	ScanDuration             time.Duration
+	SimilarImages            bool
+	SimilarNames             bool
+	SimilarityDistance       int
+	SortBy                   SortField
+	SortReverse              bool
+	Template                 string
 	ToleranceSize            float64
+	TopCount                 int
+	TreeDepth                int
+	UniqueToRoot             string
+	UseIndex                 bool
 }
 
 // DirectoryResults struct for the results
@@ -31,11 +332,159 @@ type DirectoryResult struct {
 	Count     int
 }
 
+// UsageResult struct for the disk usage of a directory and everything below it
+type UsageResult struct {
+	Directory       string
+	Size            int64
+	FileCount       int
+	LargestFile     string
+	LargestFileSize int64
+}
+
+// StatsBucket struct for the files falling into one bucket of a stats breakdown
+type StatsBucket struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Size  int64  `json:"size"`
+}
+
+// Stats struct for the breakdowns of the matched files shown by the stats command
+type Stats struct {
+	TotalCount int           `json:"totalCount"`
+	TotalSize  int64         `json:"totalSize"`
+	Extensions []StatsBucket `json:"extensions"`
+	FileTypes  []StatsBucket `json:"fileTypes"`
+	Sizes      []StatsBucket `json:"sizes"`
+	Ages       []StatsBucket `json:"ages"`
+}
+
 // EntryResult struct for more in depth entry info
 type EntryResult struct {
+	Root      string
 	Directory string
 	FileName  string
 	FileSize  string
+	Size      int64
+	ModTime   time.Time
+	// Hash and HashAlgorithm are set for entries read from an index storing hashes
+	Hash          string
+	HashAlgorithm HashAlgorithm
+}
+
+// TemplateResult struct for the fields available to --format and named
+// templates, which are executed once per found file, or once per directory
+// without detailed results
+type TemplateResult struct {
+	Root      string    // Root directory the file was found under
+	Directory string    // Directory holding the file
+	FileName  string    // Name of the file, empty without detailed results
+	Path      string    // Directory and FileName joined
+	Extension string    // Lowercase extension including the dot
+	Size      int64     // Size in bytes
+	FileSize  string    // Human readable size, like 1.50 MB
+	ModTime   time.Time // Last modification time
+	Count     int       // Number of found files in Directory, only set without detailed results
+}
+
+// DuplicateResult struct for a file that belongs to a set of identical files
+type DuplicateResult struct {
+	Set    int
+	Hash   string
+	Entry  EntryResult
+	Status DuplicateStatus
+}
+
+// SimilarImageResult struct for an image that belongs to a group of visually similar images
+type SimilarImageResult struct {
+	Group    int
+	Entry    EntryResult
+	Width    int
+	Height   int
+	Hash     uint64
+	Distance int
+}
+
+// SimilarNameResult struct for a file that belongs to a group of files with similar names
+type SimilarNameResult struct {
+	Group          int
+	Entry          EntryResult
+	NormalizedName string
+	Similarity     float64
+}
+
+// ActionResult struct for the outcome of an action on a single file
+type ActionResult struct {
+	Source      string
+	Destination string
+	Outcome     ActionOutcome
+	Message     string
+}
+
+// ExecResult struct for a single command run by --exec or --exec-batch
+type ExecResult struct {
+	Command  string
+	ExitCode int
+	Message  string
+}
+
+// QuarantineEntry struct for a single file held in a quarantine directory
+type QuarantineEntry struct {
+	OriginalPath   string        `json:"originalPath"`
+	QuarantinePath string        `json:"quarantinePath"`
+	Size           int64         `json:"size"`
+	Hash           string        `json:"hash,omitempty"`
+	HashAlgorithm  HashAlgorithm `json:"hashAlgorithm,omitempty"`
+	QuarantinedAt  time.Time     `json:"quarantinedAt"`
+}
+
+// QuarantineManifest struct for the manifest kept in a quarantine directory
+type QuarantineManifest struct {
+	Entries []QuarantineEntry `json:"entries"`
+}
+
+// ChecksumResult struct for a single file verified against a checksum manifest
+type ChecksumResult struct {
+	Path    string
+	Status  ChecksumStatus
+	Message string
+}
+
+// CompareResult struct for a file that differs between the compared
+// directories, Path is relative to both of them
+type CompareResult struct {
+	Path     string
+	Status   CompareStatus
+	SizeA    int64
+	SizeB    int64
+	ModTimeA time.Time
+	ModTimeB time.Time
+}
+
+// Snapshot struct for the files found by a scan saved with snapshot save
+type Snapshot struct {
+	Roots     []string   `json:"roots"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Files     []FileInfo `json:"files"`
+}
+
+// SnapshotChange struct for a single difference between two snapshots
+type SnapshotChange struct {
+	Change       SnapshotChangeType `json:"change"`
+	Path         string             `json:"path"`
+	PreviousPath string             `json:"previousPath,omitempty"`
+	Size         int64              `json:"size"`
+	PreviousSize int64              `json:"previousSize"`
+}
+
+// WatchEvent struct for a file that started or stopped matching the filters
+// while watching, Outcome and Message describe the action run on a new match
+type WatchEvent struct {
+	Event   WatchEventType `json:"event"`
+	Path    string         `json:"path"`
+	Size    int64          `json:"size"`
+	ModTime time.Time      `json:"modTime"`
+	Outcome ActionOutcome  `json:"outcome,omitempty"`
+	Message string         `json:"message,omitempty"`
 }
 
 // NewFileFinder initializes a new FileFinder object
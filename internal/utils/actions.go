@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+// actionFunc applies an action to a single file, src and dst are absolute paths
+type actionFunc func(src, dst string) error
+
+// actionFuncs maps each action type to its implementation, new actions only
+// need to be registered here
+var actionFuncs = map[types.ActionType]actionFunc{
+	types.ActionTypes.Move: func(src, dst string) error {
+		_, err := moveFile(src, dst)
+		return err
+	},
+	types.ActionTypes.Copy: func(src, dst string) error {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		_, err := copyAndVerify(src, dst)
+		return err
+	},
+	types.ActionTypes.Link: func(src, dst string) error {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(src, dst)
+	},
+}
+
+// ToActionType converts a string to an ActionType, returning "" when unknown
+func ToActionType(actionType string) types.ActionType {
+	for _, t := range []types.ActionType{types.ActionTypes.Move, types.ActionTypes.Copy, types.ActionTypes.Link} {
+		if strings.EqualFold(actionType, string(t)) {
+			return t
+		}
+	}
+	return ""
+}
+
+// ToCollisionStrategy converts a string to a CollisionStrategy, returning "" when unknown
+func ToCollisionStrategy(strategy string) types.CollisionStrategy {
+	for _, s := range []types.CollisionStrategy{types.CollisionStrategies.Skip, types.CollisionStrategies.Overwrite, types.CollisionStrategies.Rename} {
+		if strings.EqualFold(strategy, string(s)) {
+			return s
+		}
+	}
+	return ""
+}
+
+// ApplyAction runs the configured action on the found files and renders the
+// per-file outcomes
+func ApplyAction(results interface{}, ff types.FileFinder) {
+	entries, err := toEntryResults(results)
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	if ff.ActionType == types.ActionTypes.Move {
+		result, _ := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Are you sure you want to move these files to %s?", ff.ActionDirectory))
+		if !result {
+			pterm.Info.Println("Move cancelled.")
+			return
+		}
+	}
+
+	actionResults, err := applyActionToEntries(entries, ff)
+	if err != nil {
+		pterm.Error.Printf("error applying %s action: %v\n", ff.ActionType, err)
+		return
+	}
+
+	renderActionResultsToTable(actionResults, ff.ActionType)
+}
+
+func applyActionToEntries(entries []types.EntryResult, ff types.FileFinder) ([]types.ActionResult, error) {
+	apply, ok := actionFuncs[ff.ActionType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported action: %s", ff.ActionType)
+	}
+
+	absTarget, err := filepath.Abs(ff.ActionDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var actionResults []types.ActionResult
+	for _, entry := range entries {
+		src, err := filepath.Abs(filepath.Join(entry.Directory, entry.FileName))
+		if err != nil {
+			actionResults = append(actionResults, failedActionResult(entry.FileName, "", err))
+			continue
+		}
+
+		dst := filepath.Join(absTarget, filepath.Base(src))
+		if !ff.FlattenDirectories {
+			absRoot, err := filepath.Abs(entryRoot(entry, ff.RootDirectory))
+			if err != nil {
+				actionResults = append(actionResults, failedActionResult(src, "", err))
+				continue
+			}
+			dst = filepath.Join(absTarget, relativeToRoot(absRoot, src))
+		}
+
+		if isSameFile(src, dst) {
+			actionResults = append(actionResults, types.ActionResult{
+				Source:      src,
+				Destination: dst,
+				Outcome:     types.ActionOutcomes.Skipped,
+				Message:     "already at destination",
+			})
+			continue
+		}
+
+		dst, skip, err := resolveCollision(dst, ff.CollisionStrategy)
+		if err != nil {
+			actionResults = append(actionResults, failedActionResult(src, dst, err))
+			continue
+		}
+		if skip {
+			actionResults = append(actionResults, types.ActionResult{
+				Source:      src,
+				Destination: dst,
+				Outcome:     types.ActionOutcomes.Skipped,
+				Message:     "destination exists",
+			})
+			continue
+		}
+
+		if err := applyThroughTemporaryFile(apply, ff.ActionType, src, dst); err != nil {
+			actionResults = append(actionResults, failedActionResult(src, dst, err))
+			continue
+		}
+		actionResults = append(actionResults, types.ActionResult{
+			Source:      src,
+			Destination: dst,
+			Outcome:     types.ActionOutcomes.Done,
+		})
+	}
+
+	return actionResults, nil
+}
+
+func failedActionResult(src, dst string, err error) types.ActionResult {
+	return types.ActionResult{
+		Source:      src,
+		Destination: dst,
+		Outcome:     types.ActionOutcomes.Failed,
+		Message:     err.Error(),
+	}
+}
+
+// resolveCollision returns the destination to use given the collision
+// strategy, and whether the file should be skipped. An existing destination is
+// left in place when overwriting, it is only replaced once the action succeeded.
+func resolveCollision(dst string, strategy types.CollisionStrategy) (string, bool, error) {
+	info, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return dst, false, nil
+	}
+	if err != nil {
+		return dst, false, err
+	}
+
+	switch strategy {
+	case types.CollisionStrategies.Overwrite:
+		if info.IsDir() {
+			return dst, false, fmt.Errorf("destination %s is a directory", dst)
+		}
+		return dst, false, nil
+	case types.CollisionStrategies.Rename:
+		return nextAvailablePath(dst), false, nil
+	default:
+		return dst, true, nil
+	}
+}
+
+// applyThroughTemporaryFile applies the action to a temporary file next to dst
+// and renames it over dst afterwards, so a failed action never costs the file
+// already at dst
+func applyThroughTemporaryFile(apply actionFunc, actionType types.ActionType, src, dst string) error {
+	tmp := nextAvailablePath(filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".ff-tmp"))
+	if err := apply(src, tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		if actionType == types.ActionTypes.Move {
+			if _, moveErr := moveFile(tmp, src); moveErr != nil {
+				return fmt.Errorf("%w, the file was left at %s", err, tmp)
+			}
+		} else {
+			os.Remove(tmp)
+		}
+		return err
+	}
+	return nil
+}
+
+// isSameFile reports whether src and dst are the same file, so an action
+// never replaces a file with itself
+func isSameFile(src, dst string) bool {
+	if src == dst {
+		return true
+	}
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return false
+	}
+	dstInfo, err := os.Lstat(dst)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(srcInfo, dstInfo)
+}
+
+// isActionDirectory reports whether path is the directory found files are
+// moved, copied or linked into, so reruns do not find them there again
+func isActionDirectory(path string, ff types.FileFinder) bool {
+	if ff.ActionDirectory == "" {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absTarget, err := filepath.Abs(ff.ActionDirectory)
+	if err != nil {
+		return false
+	}
+	return absPath == absTarget
+}
+
+// entryRoot returns the root directory the entry was found under, or fallback
+// when the entry was not tagged with one
+func entryRoot(entry types.EntryResult, fallback string) string {
+	if entry.Root != "" {
+		return entry.Root
+	}
+	return fallback
+}
+
+// relativeToRoot returns the path of src relative to root, falling back to the
+// base name when src is not below root
+func relativeToRoot(root, src string) string {
+	rel, err := filepath.Rel(root, src)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(src)
+	}
+	return rel
+}
+
+func renderActionResultsToTable(actionResults []types.ActionResult, actionType types.ActionType) {
+	t := table.Table{}
+	counts := make(map[types.ActionOutcome]int)
+	t.AppendHeader(table.Row{"Source", "Destination", "Outcome"})
+	for _, result := range actionResults {
+		counts[result.Outcome]++
+
+		outcome := string(result.Outcome)
+		if result.Message != "" {
+			outcome = pterm.Sprintf("%s: %s", result.Outcome, result.Message)
+		}
+		destination := result.Destination
+		if result.Outcome == types.ActionOutcomes.Done {
+			destination = formatResultHyperLink(result.Destination, result.Destination)
+		}
+		t.AppendRow(table.Row{result.Source, destination, outcome})
+	}
+	t.AppendFooter(table.Row{
+		pterm.Sprintf("%s Total", actionType),
+		pterm.Sprintf("%v", len(actionResults)),
+		pterm.Sprintf("%d done, %d skipped, %d failed", counts[types.ActionOutcomes.Done], counts[types.ActionOutcomes.Skipped], counts[types.ActionOutcomes.Failed]),
+	})
+
+	renderTable(&t)
+}
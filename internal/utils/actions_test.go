@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestResolveCollision checks each collision strategy against an existing destination
+func TestResolveCollision(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(existing, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		strategy types.CollisionStrategy
+		expected string
+		skip     bool
+	}{
+		{"skip", types.CollisionStrategies.Skip, existing, true},
+		{"rename", types.CollisionStrategies.Rename, filepath.Join(dir, "file_1.txt"), false},
+		{"overwrite", types.CollisionStrategies.Overwrite, existing, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skip, err := resolveCollision(existing, tt.strategy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected || skip != tt.skip {
+				t.Errorf("expected (%s, %v), got (%s, %v)", tt.expected, tt.skip, got, skip)
+			}
+		})
+	}
+
+	if _, err := os.Stat(existing); err != nil {
+		t.Errorf("expected overwrite to leave the existing file until the action succeeded, got err %v", err)
+	}
+}
+
+// TestApplyActionToEntries checks where each action places a file, that an
+// overwritten destination is only replaced once the action succeeded and that
+// a file is never replaced with itself
+func TestApplyActionToEntries(t *testing.T) {
+	tests := []struct {
+		name         string
+		actionType   types.ActionType
+		flatten      bool
+		strategy     types.CollisionStrategy
+		targetIsRoot bool
+		existing     string
+		removeSource bool
+		outcome      types.ActionOutcome
+		destination  string
+		content      string
+		sourceKept   bool
+	}{
+		{name: "move preserving", actionType: types.ActionTypes.Move, outcome: types.ActionOutcomes.Done, destination: filepath.Join("sub", "file.txt"), content: "content"},
+		{name: "move flattened", actionType: types.ActionTypes.Move, flatten: true, outcome: types.ActionOutcomes.Done, destination: "file.txt", content: "content"},
+		{name: "copy preserving", actionType: types.ActionTypes.Copy, outcome: types.ActionOutcomes.Done, destination: filepath.Join("sub", "file.txt"), content: "content", sourceKept: true},
+		{name: "link flattened", actionType: types.ActionTypes.Link, flatten: true, outcome: types.ActionOutcomes.Done, destination: "file.txt", content: "content", sourceKept: true},
+		{name: "copy overwriting", actionType: types.ActionTypes.Copy, strategy: types.CollisionStrategies.Overwrite, existing: "old", outcome: types.ActionOutcomes.Done, destination: filepath.Join("sub", "file.txt"), content: "content", sourceKept: true},
+		{name: "failed copy keeps the destination", actionType: types.ActionTypes.Copy, strategy: types.CollisionStrategies.Overwrite, existing: "old", removeSource: true, outcome: types.ActionOutcomes.Failed, destination: filepath.Join("sub", "file.txt"), content: "old"},
+		{name: "copy onto itself", actionType: types.ActionTypes.Copy, strategy: types.CollisionStrategies.Overwrite, targetIsRoot: true, outcome: types.ActionOutcomes.Skipped, destination: filepath.Join("sub", "file.txt"), content: "content", sourceKept: true},
+		{name: "move onto itself", actionType: types.ActionTypes.Move, strategy: types.CollisionStrategies.Overwrite, targetIsRoot: true, outcome: types.ActionOutcomes.Skipped, destination: filepath.Join("sub", "file.txt"), content: "content", sourceKept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			sub := filepath.Join(root, "sub")
+			if err := os.MkdirAll(sub, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			src := filepath.Join(sub, "file.txt")
+			if err := os.WriteFile(src, []byte("content"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			target := t.TempDir()
+			if tt.targetIsRoot {
+				target = root
+			}
+			dst := filepath.Join(target, tt.destination)
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dst, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.removeSource {
+				if err := os.Remove(src); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ff := types.FileFinder{
+				ActionDirectory:    target,
+				ActionType:         tt.actionType,
+				CollisionStrategy:  tt.strategy,
+				FlattenDirectories: tt.flatten,
+				RootDirectory:      root,
+			}
+			results, err := applyActionToEntries([]types.EntryResult{{Directory: sub, FileName: "file.txt", Root: root}}, ff)
+			if err != nil {
+				t.Fatalf("applyActionToEntries returned error: %v", err)
+			}
+			if len(results) != 1 || results[0].Outcome != tt.outcome || results[0].Destination != dst {
+				t.Fatalf("expected %s to %s, got %+v", tt.outcome, dst, results)
+			}
+
+			content, err := os.ReadFile(dst)
+			if err != nil || string(content) != tt.content {
+				t.Errorf("expected %s to contain %q, got %q (err %v)", dst, tt.content, content, err)
+			}
+			if _, err := os.Stat(src); (err == nil) != tt.sourceKept {
+				t.Errorf("expected source kept %v, got err %v", tt.sourceKept, err)
+			}
+
+			entries, err := os.ReadDir(filepath.Dir(dst))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), ".ff-tmp") {
+					t.Errorf("expected no temporary file to be left behind, found %s", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+// TestGetFilesSkipsActionDirectory checks files already moved into a target
+// inside the root directory are not found again
+func TestGetFilesSkipsActionDirectory(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(root, "a.txt"), filepath.Join(target, "b.txt")} {
+		if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ff := types.FileFinder{
+		ActionDirectory:        target,
+		DisplayDetailedResults: true,
+		FileTypeFilter:         commonTypes.FileTypes.Any,
+		RootDirectory:          root,
+	}
+	results, count, _, err := getFiles(ff)
+	if err != nil {
+		t.Fatalf("getFiles returned error: %v", err)
+	}
+	entries := results.([]types.EntryResult)
+	if count != 1 || len(entries) != 1 || entries[0].FileName != "a.txt" {
+		t.Errorf("expected only a.txt, got %+v", entries)
+	}
+}
@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+var (
+	// bsdChecksumLine matches "ALGORITHM (path) = hash"
+	bsdChecksumLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.*)\) = ([0-9a-fA-F]+)$`)
+	// gnuChecksumLine matches "hash  path", a * in place of the second space marks binary mode
+	gnuChecksumLine = regexp.MustCompile(`^([0-9a-fA-F]+) [ *](.*)$`)
+	// checksumEscaper escapes file names the way sha256sum does
+	checksumEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	checksumUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+)
+
+// ToChecksumFormat converts a string to a ChecksumFormat, returning "" when unknown
+func ToChecksumFormat(format string) types.ChecksumFormat {
+	for _, f := range []types.ChecksumFormat{types.ChecksumFormats.GNU, types.ChecksumFormats.BSD} {
+		if strings.EqualFold(format, string(f)) {
+			return f
+		}
+	}
+	return ""
+}
+
+// WriteChecksums hashes the matching files under the root directories and
+// writes them to manifestFile. Paths are stored relative to the manifest so
+// sha256sum -c, or the tool matching the hash, can check it from the
+// manifest's directory.
+func WriteChecksums(ff types.FileFinder, manifestFile string, format types.ChecksumFormat) error {
+	ff.DisplayDetailedResults = true
+	results, _, _, err := getFilesFromRoots(ff)
+	if err != nil {
+		return err
+	}
+
+	absManifest, err := filepath.Abs(manifestFile)
+	if err != nil {
+		return err
+	}
+	manifestDirectory := filepath.Dir(absManifest)
+
+	var paths []string
+	for _, entry := range results.([]types.EntryResult) {
+		path, err := filepath.Abs(entryPath(entry))
+		if err != nil {
+			return err
+		}
+		if path == absManifest {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hasher := getHasher(ff.HashAlgorithm)
+	var sb strings.Builder
+	var count int
+	for i, hash := range hashPaths(hasher, paths) {
+		if hash == "" {
+			continue
+		}
+		name, err := filepath.Rel(manifestDirectory, paths[i])
+		if err != nil {
+			name = paths[i]
+		}
+		sb.WriteString(formatChecksumLine(format, hasher.Algorithm(), hash, filepath.ToSlash(name)))
+		sb.WriteString("\n")
+		count++
+	}
+
+	tmp := absManifest + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, absManifest); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Wrote checksums of %d files to %s\n", count, manifestFile)
+	return nil
+}
+
+// VerifyChecksums rehashes every file listed in manifestFile and reports the
+// ones that changed or are missing, returning an error when there are any so
+// scripts can detect them
+func VerifyChecksums(manifestFile string) error {
+	results, err := verifyChecksums(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != types.ChecksumStatuses.OK {
+			failed++
+		}
+	}
+	if failed == 0 {
+		pterm.Success.Printf("All %d files OK\n", len(results))
+		return nil
+	}
+
+	renderChecksumResultsToTable(results)
+	return fmt.Errorf("%d of %d files failed verification", failed, len(results))
+}
+
+func verifyChecksums(manifestFile string) ([]types.ChecksumResult, error) {
+	f, err := os.Open(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	absManifest, err := filepath.Abs(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	manifestDirectory := filepath.Dir(absManifest)
+
+	// Files are hashed together per algorithm so they are read in parallel
+	type pendingFile struct {
+		result int
+		hash   string
+		path   string
+	}
+	var results []types.ChecksumResult
+	pending := make(map[types.HashAlgorithm][]pendingFile)
+
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		algorithm, hash, name, err := parseChecksumLine(line)
+		if err != nil {
+			pterm.Warning.Printf("%s:%d: %v\n", manifestFile, lineNumber, err)
+			continue
+		}
+
+		path := filepath.FromSlash(name)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(manifestDirectory, path)
+		}
+
+		result := types.ChecksumResult{Path: name, Status: types.ChecksumStatuses.OK}
+		if _, err := os.Stat(path); err != nil {
+			result.Status = types.ChecksumStatuses.Missing
+			if !errors.Is(err, os.ErrNotExist) {
+				result.Message = err.Error()
+			}
+		} else {
+			pending[algorithm] = append(pending[algorithm], pendingFile{result: len(results), hash: hash, path: path})
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for algorithm, files := range pending {
+		paths := make([]string, 0, len(files))
+		for _, file := range files {
+			paths = append(paths, file.path)
+		}
+
+		for i, hash := range hashPaths(getHasher(algorithm), paths) {
+			result := &results[files[i].result]
+			switch {
+			case hash == "":
+				result.Status = types.ChecksumStatuses.Failed
+				result.Message = "unreadable"
+			case !strings.EqualFold(hash, files[i].hash):
+				result.Status = types.ChecksumStatuses.Failed
+				result.Message = pterm.Sprintf("%s mismatch", algorithm)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// formatChecksumLine formats a manifest line, names containing a backslash or
+// line break are escaped and the line prefixed with a backslash like sha256sum does
+func formatChecksumLine(format types.ChecksumFormat, algorithm types.HashAlgorithm, hash, name string) string {
+	prefix := ""
+	if escaped := checksumEscaper.Replace(name); escaped != name {
+		prefix = `\`
+		name = escaped
+	}
+
+	if format == types.ChecksumFormats.BSD {
+		return fmt.Sprintf("%s%s (%s) = %s", prefix, strings.ToUpper(string(algorithm)), name, hash)
+	}
+	return fmt.Sprintf("%s%s  %s", prefix, hash, name)
+}
+
+// parseChecksumLine returns the algorithm, hash and file name of a GNU or BSD
+// style manifest line. GNU style lines do not name the algorithm so it is
+// told apart by the length of the hash.
+func parseChecksumLine(line string) (types.HashAlgorithm, string, string, error) {
+	escaped := strings.HasPrefix(line, `\`)
+	if escaped {
+		line = line[1:]
+	}
+
+	var algorithm types.HashAlgorithm
+	var hash, name string
+	if match := bsdChecksumLine.FindStringSubmatch(line); match != nil {
+		algorithm = ToHashAlgorithm(match[1])
+		if algorithm == "" {
+			return "", "", "", fmt.Errorf("unsupported checksum algorithm: %s", match[1])
+		}
+		name, hash = match[2], match[3]
+	} else if match := gnuChecksumLine.FindStringSubmatch(line); match != nil {
+		hash, name = match[1], match[2]
+		algorithm = hashAlgorithmForLength(len(hash))
+		if algorithm == "" {
+			return "", "", "", fmt.Errorf("no supported checksum algorithm has %d hex digits", len(hash))
+		}
+	} else {
+		return "", "", "", errors.New("improperly formatted checksum line")
+	}
+
+	if len(hash) != 2*getHasher(algorithm).New().Size() {
+		return "", "", "", fmt.Errorf("invalid %s checksum: %s", algorithm, hash)
+	}
+	if escaped {
+		name = checksumUnescaper.Replace(name)
+	}
+	return algorithm, hash, name, nil
+}
+
+// hashAlgorithmForLength returns the algorithm producing hex encoded hashes of
+// the given length, every supported algorithm has a different one
+func hashAlgorithmForLength(length int) types.HashAlgorithm {
+	for algorithm, hasher := range hashers {
+		if 2*hasher.New().Size() == length {
+			return algorithm
+		}
+	}
+	return ""
+}
+
+func renderChecksumResultsToTable(results []types.ChecksumResult) {
+	t := table.Table{}
+	counts := make(map[types.ChecksumStatus]int)
+	t.AppendHeader(table.Row{"Status", "Path", "Message"})
+	for _, result := range results {
+		counts[result.Status]++
+		// Only problems are listed, an archive can hold a lot of healthy files
+		if result.Status == types.ChecksumStatuses.OK {
+			continue
+		}
+		t.AppendRow(table.Row{string(result.Status), result.Path, result.Message})
+	}
+	t.AppendFooter(table.Row{
+		"Total Files",
+		pterm.Sprintf("%v", len(results)),
+		pterm.Sprintf("%d ok, %d failed, %d missing", counts[types.ChecksumStatuses.OK], counts[types.ChecksumStatuses.Failed], counts[types.ChecksumStatuses.Missing]),
+	})
+
+	renderTable(&t)
+}
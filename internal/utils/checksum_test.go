@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestChecksumLines checks manifest lines round trip in both formats, including escaped names
+func TestChecksumLines(t *testing.T) {
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		format   types.ChecksumFormat
+		name     string
+		expected string
+	}{
+		{types.ChecksumFormats.GNU, "docs/a b.txt", hash + "  docs/a b.txt"},
+		{types.ChecksumFormats.BSD, "docs/a b.txt", "SHA256 (docs/a b.txt) = " + hash},
+		{types.ChecksumFormats.GNU, "odd\\name\n.txt", `\` + hash + `  odd\\name\n.txt`},
+		{types.ChecksumFormats.BSD, "odd\\name\n.txt", `\SHA256 (odd\\name\n.txt) = ` + hash},
+	}
+
+	for _, tt := range tests {
+		line := formatChecksumLine(tt.format, types.HashAlgorithms.SHA256, hash, tt.name)
+		if line != tt.expected {
+			t.Errorf("formatChecksumLine(%s, %q) = %q, expected %q", tt.format, tt.name, line, tt.expected)
+		}
+
+		algorithm, parsedHash, parsedName, err := parseChecksumLine(line)
+		if err != nil || algorithm != types.HashAlgorithms.SHA256 || parsedHash != hash || parsedName != tt.name {
+			t.Errorf("parseChecksumLine(%q) = %s, %q, %q, %v", line, algorithm, parsedHash, parsedName, err)
+		}
+	}
+
+	// GNU style lines are told apart by the length of the hash
+	if algorithm, _, _, err := parseChecksumLine("d41d8cd98f00b204e9800998ecf8427e  a.txt"); err != nil || algorithm != types.HashAlgorithms.MD5 {
+		t.Errorf("expected an md5 line, got %s, %v", algorithm, err)
+	}
+
+	if _, _, _, err := parseChecksumLine("WHIRLPOOL (a.txt) = d41d8cd98f00b204e9800998ecf8427e"); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
+
+// TestVerifyChecksums checks changed and deleted files are reported after writing a manifest
+func TestVerifyChecksums(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"kept.txt", "changed.txt", "deleted.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := filepath.Join(root, "SHA256SUMS")
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, RootDirectories: []string{root}, RootDirectory: root}
+	if err := WriteChecksums(ff, manifest, types.ChecksumFormats.BSD); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "changed.txt"), []byte("bit rot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyChecksums(manifest); err == nil || err.Error() != "2 of 3 files failed verification" {
+		t.Errorf("expected verification to fail for 2 of 3 files, got %v", err)
+	}
+
+	results, err := verifyChecksums(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]types.ChecksumStatus{
+		"kept.txt":    types.ChecksumStatuses.OK,
+		"changed.txt": types.ChecksumStatuses.Failed,
+		"deleted.txt": types.ChecksumStatuses.Missing,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %+v", len(expected), results)
+	}
+	for _, result := range results {
+		if result.Status != expected[result.Path] {
+			t.Errorf("%s is %s, expected %s", result.Path, result.Status, expected[result.Path])
+		}
+	}
+}
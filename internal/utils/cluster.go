@@ -0,0 +1,76 @@
+package utils
+
+import "sort"
+
+// clusterIndices groups the indices 0..n-1 so that any two indices for which
+// related returns true end up in the same group. Only groups with more than
+// one member are returned, each sorted, ordered by their first index.
+func clusterIndices(n int, related func(i, j int) bool) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if find(i) != find(j) && related(i, j) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, group := range groups {
+		if len(group) > 1 {
+			clusters = append(clusters, group)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+// clusterAroundFirst groups the indices 0..n-1 around representatives: each
+// index joins the group of the first earlier representative it is related to,
+// or becomes a new representative. Unlike clusterIndices matches are not
+// chained, every member is related to its group's first index. Only groups
+// with more than one member are returned, ordered by their first index.
+func clusterAroundFirst(n int, related func(i, j int) bool) [][]int {
+	var groups [][]int
+	for i := 0; i < n; i++ {
+		joined := false
+		for g, group := range groups {
+			if related(group[0], i) {
+				groups[g] = append(group, i)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	var clusters [][]int
+	for _, group := range groups {
+		if len(group) > 1 {
+			clusters = append(clusters, group)
+		}
+	}
+	return clusters
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// CompareDirectories lists the files that differ between dirA and dirB using
+// the filters in ff. When syncListFile is set the paths, relative to dirA, of
+// the files needed to make dirB match dirA are written to it one per line.
+func CompareDirectories(ff types.FileFinder, dirA, dirB string, compareContent bool, syncListFile string) error {
+	results, err := compareDirectories(ff, dirA, dirB, compareContent)
+	if err != nil {
+		return err
+	}
+
+	if syncListFile != "" {
+		if err := writeSyncList(syncListFile, results); err != nil {
+			return err
+		}
+	}
+
+	if len(results) == 0 {
+		pterm.Success.Printf("%s and %s contain the same matching files\n", dirA, dirB)
+		return nil
+	}
+
+	renderCompareResultsToTable(results, dirA, dirB)
+	return nil
+}
+
+func compareDirectories(ff types.FileFinder, dirA, dirB string, compareContent bool) ([]types.CompareResult, error) {
+	filesA, err := relativeEntries(ff, dirA)
+	if err != nil {
+		return nil, err
+	}
+	filesB, err := relativeEntries(ff, dirB)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []types.CompareResult
+	var pending []types.CompareResult
+	var pendingPaths []string
+	for path, a := range filesA {
+		result := types.CompareResult{Path: path, SizeA: a.Size, ModTimeA: a.ModTime}
+
+		b, ok := filesB[path]
+		if !ok {
+			result.Status = types.CompareStatuses.OnlyInA
+			results = append(results, result)
+			continue
+		}
+		result.SizeB = b.Size
+		result.ModTimeB = b.ModTime
+
+		switch {
+		case a.Size != b.Size:
+			result.Status = types.CompareStatuses.SizeDiffers
+			results = append(results, result)
+		case compareContent:
+			// Hashed together below so the files are read in parallel
+			pending = append(pending, result)
+			pendingPaths = append(pendingPaths, entryPath(a), entryPath(b))
+		case !a.ModTime.Equal(b.ModTime):
+			result.Status = types.CompareStatuses.ModTimeDiffers
+			results = append(results, result)
+		}
+	}
+	for path, b := range filesB {
+		if _, ok := filesA[path]; !ok {
+			results = append(results, types.CompareResult{Path: path, Status: types.CompareStatuses.OnlyInB, SizeB: b.Size, ModTimeB: b.ModTime})
+		}
+	}
+
+	hashes := hashPaths(getHasher(ff.HashAlgorithm), pendingPaths)
+	for i, result := range pending {
+		hashA, hashB := hashes[2*i], hashes[2*i+1]
+		if hashA == "" || hashB == "" || hashA != hashB {
+			result.Status = types.CompareStatuses.ContentDiffers
+			results = append(results, result)
+		}
+	}
+
+	order := map[types.CompareStatus]int{
+		types.CompareStatuses.OnlyInA:        0,
+		types.CompareStatuses.OnlyInB:        1,
+		types.CompareStatuses.SizeDiffers:    2,
+		types.CompareStatuses.ModTimeDiffers: 3,
+		types.CompareStatuses.ContentDiffers: 4,
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Status != results[j].Status {
+			return order[results[i].Status] < order[results[j].Status]
+		}
+		return results[i].Path < results[j].Path
+	})
+
+	return results, nil
+}
+
+// relativeEntries scans dir and returns the matching files by their path relative to dir
+func relativeEntries(ff types.FileFinder, dir string) (map[string]types.EntryResult, error) {
+	ff.DisplayDetailedResults = true
+	ff.RootDirectory = dir
+	ff.RootDirectories = []string{dir}
+
+	results, _, _, err := getFilesFromRoots(ff)
+	if err != nil {
+		return nil, err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]types.EntryResult)
+	for _, entry := range results.([]types.EntryResult) {
+		absPath, err := filepath.Abs(entryPath(entry))
+		if err != nil {
+			return nil, err
+		}
+		entries[relativeToRoot(absDir, absPath)] = entry
+	}
+	return entries, nil
+}
+
+// writeSyncList writes the files missing from or differing in B, the ones to
+// copy from A to make B match
+func writeSyncList(syncListFile string, results []types.CompareResult) error {
+	var sb strings.Builder
+	for _, result := range results {
+		if result.Status == types.CompareStatuses.OnlyInB {
+			continue
+		}
+		sb.WriteString(filepath.ToSlash(result.Path))
+		sb.WriteString("\n")
+	}
+	return os.WriteFile(syncListFile, []byte(sb.String()), 0o644)
+}
+
+func renderCompareResultsToTable(results []types.CompareResult, dirA, dirB string) {
+	t := table.Table{}
+	describe := func(size int64, modTime time.Time) string {
+		if modTime.IsZero() {
+			return ""
+		}
+		return pterm.Sprintf("%s, %s", commonFormatters.FormatSize(size), modTime.Format(time.DateTime))
+	}
+
+	counts := make(map[types.CompareStatus]int)
+	t.AppendHeader(table.Row{"Status", "Path", pterm.Sprintf("A: %s", dirA), pterm.Sprintf("B: %s", dirB)})
+	for _, result := range results {
+		counts[result.Status]++
+		t.AppendRow(table.Row{string(result.Status), result.Path, describe(result.SizeA, result.ModTimeA), describe(result.SizeB, result.ModTimeB)})
+	}
+	t.AppendFooter(table.Row{
+		"Total Differences",
+		pterm.Sprintf("%v", len(results)),
+		pterm.Sprintf("%d only in A, %d only in B", counts[types.CompareStatuses.OnlyInA], counts[types.CompareStatuses.OnlyInB]),
+		pterm.Sprintf("%d size, %d modified time, %d content", counts[types.CompareStatuses.SizeDiffers], counts[types.CompareStatuses.ModTimeDiffers], counts[types.CompareStatuses.ContentDiffers]),
+	})
+
+	renderTable(&t)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestCompareDirectories checks files are reported by how they differ, with
+// modification times only compared when content is not
+func TestCompareDirectories(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	write := func(dir, name, content string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(dirA, "same.txt", "same", modTime)
+	write(dirB, "same.txt", "same", modTime)
+	write(dirA, "sub/only-a.txt", "a", modTime)
+	write(dirB, "only-b.txt", "b", modTime)
+	write(dirA, "size.txt", "short", modTime)
+	write(dirB, "size.txt", "longer", modTime)
+	write(dirA, "touched.txt", "touched", modTime)
+	write(dirB, "touched.txt", "touched", modTime.Add(time.Minute))
+	write(dirA, "edited.txt", "before", modTime)
+	write(dirB, "edited.txt", "after!", modTime.Add(time.Minute))
+
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any}
+
+	tests := []struct {
+		compareContent bool
+		expected       map[string]types.CompareStatus
+	}{
+		{false, map[string]types.CompareStatus{
+			filepath.Join("sub", "only-a.txt"): types.CompareStatuses.OnlyInA,
+			"only-b.txt":                       types.CompareStatuses.OnlyInB,
+			"size.txt":                         types.CompareStatuses.SizeDiffers,
+			"touched.txt":                      types.CompareStatuses.ModTimeDiffers,
+			"edited.txt":                       types.CompareStatuses.ModTimeDiffers,
+		}},
+		{true, map[string]types.CompareStatus{
+			filepath.Join("sub", "only-a.txt"): types.CompareStatuses.OnlyInA,
+			"only-b.txt":                       types.CompareStatuses.OnlyInB,
+			"size.txt":                         types.CompareStatuses.SizeDiffers,
+			"edited.txt":                       types.CompareStatuses.ContentDiffers,
+		}},
+	}
+
+	for _, tt := range tests {
+		results, err := compareDirectories(ff, dirA, dirB, tt.compareContent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(tt.expected) {
+			t.Errorf("compareContent=%v: expected %d results, got %+v", tt.compareContent, len(tt.expected), results)
+			continue
+		}
+		for _, result := range results {
+			if result.Status != tt.expected[result.Path] {
+				t.Errorf("compareContent=%v: %s is %q, expected %q", tt.compareContent, result.Path, result.Status, tt.expected[result.Path])
+			}
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+// duplicateSet is a group of files with identical content and the hash they share
+type duplicateSet struct {
+	hash    string
+	entries []types.EntryResult
+}
+
+// ToKeepPolicy converts a string to a KeepPolicy, returning "" when unknown
+func ToKeepPolicy(policy string) types.KeepPolicy {
+	for _, p := range []types.KeepPolicy{
+		types.KeepPolicies.Oldest,
+		types.KeepPolicies.Newest,
+		types.KeepPolicies.ShortestPath,
+		types.KeepPolicies.LongestPath,
+		types.KeepPolicies.Preferred,
+		types.KeepPolicies.First,
+	} {
+		if strings.EqualFold(policy, string(p)) {
+			return p
+		}
+	}
+	return ""
+}
+
+// findAndDisplayDuplicates groups the entries into sets of identical files,
+// applies the keep policy and renders the sets
+func findAndDisplayDuplicates(entries []types.EntryResult, ff types.FileFinder) []types.DuplicateResult {
+	sets := findDuplicateSets(entries, getHasher(ff.HashAlgorithm))
+	if collisionProne(ff.HashAlgorithm) && actsOnFiles(ff) {
+		sets = confirmDuplicateSets(sets)
+	}
+	if ff.CrossRootDuplicates {
+		sets = crossRootSets(sets)
+	}
+
+	if len(sets) == 0 {
+		pterm.Info.Println("0 duplicate files found matching criteria")
+		return []types.DuplicateResult{}
+	}
+
+	duplicates := applyKeepPolicy(sets, ff.KeepPolicy, ff.PreferredPrefixes, ff.RootDirectories)
+	if ff.OutputFormat == types.OutputFormats.HTML {
+		renderDuplicatesToHTML(duplicates, len(sets), ff)
+	} else {
+		renderDuplicatesToTable(duplicates, len(sets), len(ff.RootDirectories) > 1)
+	}
+
+	return duplicates
+}
+
+// findDuplicateSets returns the sets of entries with identical content. Only
+// entries sharing a size with another entry are hashed.
+func findDuplicateSets(entries []types.EntryResult, hasher Hasher) []duplicateSet {
+	bySize := make(map[int64][]types.EntryResult)
+	for _, entry := range entries {
+		if entry.Size > 0 {
+			bySize[entry.Size] = append(bySize[entry.Size], entry)
+		}
+	}
+
+	var candidates []types.EntryResult
+	for _, group := range bySize {
+		if len(group) > 1 {
+			candidates = append(candidates, group...)
+		}
+	}
+
+	hashes := hashEntries(candidates, hasher)
+
+	byHash := make(map[string][]types.EntryResult)
+	for i, entry := range candidates {
+		if hashes[i] != "" {
+			byHash[hashes[i]] = append(byHash[hashes[i]], entry)
+		}
+	}
+
+	return sortedDuplicateSets(byHash)
+}
+
+// confirmDuplicateSets rehashes the files of every set with SHA-256, the same
+// check hardlinkFile makes, and splits the sets whose files only shared a
+// colliding fast hash
+func confirmDuplicateSets(sets []duplicateSet) []duplicateSet {
+	var entries []types.EntryResult
+	for _, set := range sets {
+		entries = append(entries, set.entries...)
+	}
+	hashes := hashEntries(entries, getHasher(types.HashAlgorithms.SHA256))
+
+	byHash := make(map[string][]types.EntryResult)
+	for i, entry := range entries {
+		if hashes[i] != "" {
+			byHash[hashes[i]] = append(byHash[hashes[i]], entry)
+		}
+	}
+	return sortedDuplicateSets(byHash)
+}
+
+// sortedDuplicateSets returns the groups of more than one file sorted by path,
+// largest sets first so the most space is shown at the top
+func sortedDuplicateSets(byHash map[string][]types.EntryResult) []duplicateSet {
+	var sets []duplicateSet
+	for hash, group := range byHash {
+		if len(group) > 1 {
+			sort.Slice(group, func(i, j int) bool {
+				return entryPath(group[i]) < entryPath(group[j])
+			})
+			sets = append(sets, duplicateSet{hash: hash, entries: group})
+		}
+	}
+
+	sort.Slice(sets, func(i, j int) bool {
+		a, b := sets[i].entries[0], sets[j].entries[0]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return entryPath(a) < entryPath(b)
+	})
+
+	return sets
+}
+
+// actsOnFiles reports whether the found files are removed, quarantined, moved
+// or handed to a command after they are listed
+func actsOnFiles(ff types.FileFinder) bool {
+	return ff.RemoveFiles || ff.QuarantineDirectory != "" || ff.ActionType == types.ActionTypes.Move || ff.ExecCommand != "" || ff.ExecBatchCommand != ""
+}
+
+// crossRootSets keeps only the sets with files under more than one root directory
+func crossRootSets(sets []duplicateSet) []duplicateSet {
+	var filtered []duplicateSet
+	for _, set := range sets {
+		roots := make(map[string]bool)
+		for _, entry := range set.entries {
+			roots[entry.Root] = true
+		}
+		if len(roots) > 1 {
+			filtered = append(filtered, set)
+		}
+	}
+	return filtered
+}
+
+// findAndDisplayUniqueFiles renders and returns the entries under
+// ff.UniqueToRoot that have no identical copy under any other root
+func findAndDisplayUniqueFiles(entries []types.EntryResult, ff types.FileFinder) []types.EntryResult {
+	var inRoot, others []types.EntryResult
+	inRootSizes := make(map[int64]bool)
+	otherSizes := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.Root == ff.UniqueToRoot {
+			inRoot = append(inRoot, entry)
+			inRootSizes[entry.Size] = true
+		} else {
+			others = append(others, entry)
+			otherSizes[entry.Size] = true
+		}
+	}
+
+	// Only files sharing a size with a file on the other side need hashing
+	var candidates []types.EntryResult
+	for _, entry := range inRoot {
+		if otherSizes[entry.Size] {
+			candidates = append(candidates, entry)
+		}
+	}
+	for _, entry := range others {
+		if inRootSizes[entry.Size] {
+			candidates = append(candidates, entry)
+		}
+	}
+
+	hashes := hashEntries(candidates, getHasher(ff.HashAlgorithm))
+	hashByPath := make(map[string]string, len(candidates))
+	otherHashes := make(map[string]bool)
+	for i, entry := range candidates {
+		hashByPath[entryPath(entry)] = hashes[i]
+		if entry.Root != ff.UniqueToRoot && hashes[i] != "" {
+			otherHashes[hashes[i]] = true
+		}
+	}
+
+	unique := []types.EntryResult{}
+	var totalFileSize int64
+	for _, entry := range inRoot {
+		hash, hashed := hashByPath[entryPath(entry)]
+		if hashed && hash != "" && otherHashes[hash] {
+			continue
+		}
+		unique = append(unique, entry)
+		totalFileSize += entry.Size
+	}
+
+	if len(unique) == 0 {
+		pterm.Info.Printf("0 files unique to %s found matching criteria\n", ff.UniqueToRoot)
+		return unique
+	}
+
+	renderResults(unique, len(unique), totalFileSize, ff)
+	return unique
+}
+
+// hashEntries hashes the entries behind a spinner, unreadable files get an
+// empty hash. Hashes stored in the index are reused while the file's size and
+// modification time still match the index.
+func hashEntries(entries []types.EntryResult, hasher Hasher) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, len(entries))
+	var missing []int
+	var paths []string
+	for i, entry := range entries {
+		if storedHashValid(entry, hasher) {
+			hashes[i] = entry.Hash
+			continue
+		}
+		missing = append(missing, i)
+		paths = append(paths, entryPath(entry))
+	}
+	if len(paths) == 0 {
+		return hashes
+	}
+
+	spinner := startSpinner(fmt.Sprintf("Hashing %d candidate files...", len(paths)))
+	defer spinner.Stop()
+
+	for i, hash := range hashPaths(hasher, paths) {
+		hashes[missing[i]] = hash
+	}
+	return hashes
+}
+
+// storedHashValid reports whether the entry's stored hash was made by hasher
+// and the file did not change since
+func storedHashValid(entry types.EntryResult, hasher Hasher) bool {
+	if entry.Hash == "" || entry.HashAlgorithm != hasher.Algorithm() {
+		return false
+	}
+	info, err := os.Stat(entryPath(entry))
+	return err == nil && info.Size() == entry.Size && info.ModTime().Equal(entry.ModTime)
+}
+
+// applyKeepPolicy flattens the sets into duplicate results, marking the file
+// chosen by the policy as KEEP and the others as REMOVE
+func applyKeepPolicy(sets []duplicateSet, policy types.KeepPolicy, preferredPrefixes, roots []string) []types.DuplicateResult {
+	var duplicates []types.DuplicateResult
+	for i, set := range sets {
+		keep := -1
+		if policy != "" {
+			keep = keepIndex(set.entries, policy, preferredPrefixes, roots)
+		}
+
+		for j, entry := range set.entries {
+			var status types.DuplicateStatus
+			switch {
+			case keep < 0:
+			case j == keep:
+				status = types.DuplicateStatuses.Keep
+			default:
+				status = types.DuplicateStatuses.Remove
+			}
+			duplicates = append(duplicates, types.DuplicateResult{
+				Set:    i + 1,
+				Hash:   set.hash,
+				Entry:  entry,
+				Status: status,
+			})
+		}
+	}
+	return duplicates
+}
+
+// keepIndex returns the index of the entry in set to keep under the policy,
+// sets are sorted by path so ties always resolve to the first path
+func keepIndex(set []types.EntryResult, policy types.KeepPolicy, preferredPrefixes, roots []string) int {
+	better := map[types.KeepPolicy]func(a, b types.EntryResult) bool{
+		types.KeepPolicies.Oldest: func(a, b types.EntryResult) bool {
+			return a.ModTime.Before(b.ModTime)
+		},
+		types.KeepPolicies.Newest: func(a, b types.EntryResult) bool {
+			return a.ModTime.After(b.ModTime)
+		},
+		types.KeepPolicies.ShortestPath: func(a, b types.EntryResult) bool {
+			return len(entryPath(a)) < len(entryPath(b))
+		},
+		types.KeepPolicies.LongestPath: func(a, b types.EntryResult) bool {
+			return len(entryPath(a)) > len(entryPath(b))
+		},
+		types.KeepPolicies.Preferred: func(a, b types.EntryResult) bool {
+			return preferredRank(a, preferredPrefixes) < preferredRank(b, preferredPrefixes)
+		},
+		types.KeepPolicies.First: func(a, b types.EntryResult) bool {
+			return rootRank(a, roots) < rootRank(b, roots)
+		},
+	}[policy]
+
+	keep := 0
+	for i := 1; i < len(set); i++ {
+		if better(set[i], set[keep]) {
+			keep = i
+		}
+	}
+	return keep
+}
+
+// preferredRank returns the index of the first prefix matching the entry's
+// path, or len(prefixes) when none does
+func preferredRank(entry types.EntryResult, prefixes []string) int {
+	path := entryPath(entry)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for i, prefix := range prefixes {
+		if abs, err := filepath.Abs(prefix); err == nil {
+			prefix = abs
+		}
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, string(os.PathSeparator))+string(os.PathSeparator)) {
+			return i
+		}
+	}
+	return len(prefixes)
+}
+
+// toEntryResults returns the entries actions should apply to. For duplicates
+// these are the ones marked for removal, or all of them when no keep policy is
+// set, for similar images and names every file in a group.
+func toEntryResults(results interface{}) ([]types.EntryResult, error) {
+	switch v := results.(type) {
+	case []types.EntryResult:
+		return v, nil
+	case []types.DuplicateResult:
+		entries := []types.EntryResult{}
+		for _, duplicate := range v {
+			if duplicate.Status != types.DuplicateStatuses.Keep {
+				entries = append(entries, duplicate.Entry)
+			}
+		}
+		return entries, nil
+	case []types.SimilarImageResult:
+		entries := make([]types.EntryResult, 0, len(v))
+		for _, result := range v {
+			entries = append(entries, result.Entry)
+		}
+		return entries, nil
+	case []types.SimilarNameResult:
+		entries := make([]types.EntryResult, 0, len(v))
+		for _, result := range v {
+			entries = append(entries, result.Entry)
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("invalid data format: expected []EntryResults or []DuplicateResult, got %T", results)
+	}
+}
+
+// rootRank returns the position of the entry's root in the given root order
+func rootRank(entry types.EntryResult, roots []string) int {
+	for i, root := range roots {
+		if entry.Root == root {
+			return i
+		}
+	}
+	return len(roots)
+}
+
+func entryPath(entry types.EntryResult) string {
+	return filepath.Join(entry.Directory, entry.FileName)
+}
+
+// reclaimableSize returns the space freed by removing the duplicates marked
+// for removal, or every copy but one per set without a keep policy
+func reclaimableSize(duplicates []types.DuplicateResult) int64 {
+	var reclaimable int64
+	seenSets := make(map[int]bool)
+	for _, duplicate := range duplicates {
+		if duplicate.Status == types.DuplicateStatuses.Remove || (duplicate.Status == "" && seenSets[duplicate.Set]) {
+			reclaimable += duplicate.Entry.Size
+		}
+		seenSets[duplicate.Set] = true
+	}
+	return reclaimable
+}
+
+func renderDuplicatesToTable(duplicates []types.DuplicateResult, setCount int, showRoot bool) {
+	t := table.Table{}
+	header := table.Row{"Set", "Directory", "FileName", "FileSize", "Status"}
+	if showRoot {
+		header = table.Row{"Set", "Root", "Directory", "FileName", "FileSize", "Status"}
+	}
+	t.AppendHeader(header)
+	for _, duplicate := range duplicates {
+		status := string(duplicate.Status)
+		switch duplicate.Status {
+		case types.DuplicateStatuses.Keep:
+			status = pterm.Green(status)
+		case types.DuplicateStatuses.Remove:
+			status = pterm.Red(status)
+		}
+
+		row := table.Row{
+			formatResultHyperLink(duplicate.Entry.Directory, duplicate.Entry.Directory),
+			formatResultHyperLink(entryPath(duplicate.Entry), duplicate.Entry.FileName),
+			duplicate.Entry.FileSize,
+			status,
+		}
+		if showRoot {
+			row = append(table.Row{duplicate.Entry.Root}, row...)
+		}
+		t.AppendRow(append(table.Row{pterm.Sprintf("%v", duplicate.Set)}, row...))
+	}
+
+	footer := table.Row{
+		"Total",
+		pterm.Sprintf("%v", len(duplicates)),
+		commonFormatters.FormatSize(reclaimableSize(duplicates)),
+		"Reclaimable",
+	}
+	if showRoot {
+		footer = append(table.Row{""}, footer...)
+	}
+	t.AppendFooter(append(table.Row{pterm.Sprintf("%v Sets", setCount)}, footer...))
+
+	renderTable(&t)
+}
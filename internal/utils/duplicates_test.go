@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestFindDuplicateSets checks that only files with identical content are grouped
+func TestFindDuplicateSets(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "same", "b.txt": "same", "c.txt": "diff"}
+
+	var entries []types.EntryResult
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, types.EntryResult{Directory: dir, FileName: name, Size: int64(len(content))})
+	}
+
+	sets := findDuplicateSets(entries, getHasher(types.HashAlgorithms.SHA256))
+	if len(sets) != 1 || len(sets[0].entries) != 2 {
+		t.Fatalf("expected one set of two files, got %+v", sets)
+	}
+	if sets[0].entries[0].FileName != "a.txt" || sets[0].entries[1].FileName != "b.txt" {
+		t.Errorf("expected set sorted by path, got %+v", sets[0])
+	}
+
+	expectedHash, _ := hashFile(getHasher(types.HashAlgorithms.SHA256), filepath.Join(dir, "a.txt"))
+	if duplicates := applyKeepPolicy(sets, "", nil, nil); duplicates[0].Hash != expectedHash || duplicates[1].Hash != expectedHash {
+		t.Errorf("expected both duplicates to carry the hash %s, got %+v", expectedHash, duplicates)
+	}
+
+	if crossRoot := crossRootSets(sets); len(crossRoot) != 0 {
+		t.Errorf("expected no cross root sets when every file shares a root, got %+v", crossRoot)
+	}
+}
+
+// TestOverlappingRoots checks files below a root nested in another root are
+// found once and tagged with the nested root, so --cross-root and --unique-to
+// tell the two roots apart
+func TestOverlappingRoots(t *testing.T) {
+	outer := t.TempDir()
+	inner := filepath.Join(outer, "inner")
+	if err := os.MkdirAll(inner, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(outer, "a.txt"): "shared",
+		filepath.Join(outer, "b.txt"): "twin",
+		filepath.Join(outer, "c.txt"): "twin",
+		filepath.Join(inner, "d.txt"): "shared",
+		filepath.Join(inner, "e.txt"): "unique",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ff := types.FileFinder{
+		DisplayDetailedResults: true,
+		FileTypeFilter:         commonTypes.FileTypes.Any,
+		HashAlgorithm:          types.HashAlgorithms.SHA256,
+		RootDirectories:        []string{outer, inner},
+	}
+	results, count, _, err := getFilesFromRoots(ff)
+	if err != nil {
+		t.Fatalf("getFilesFromRoots returned error: %v", err)
+	}
+	entries := results.([]types.EntryResult)
+	if count != len(files) || len(entries) != len(files) {
+		t.Fatalf("expected every file once, got %d: %+v", count, entries)
+	}
+	for _, entry := range entries {
+		expectedRoot := outer
+		if entry.Directory == inner {
+			expectedRoot = inner
+		}
+		if entry.Root != expectedRoot {
+			t.Errorf("expected %s to be tagged with %s, got %s", entry.FileName, expectedRoot, entry.Root)
+		}
+	}
+
+	sets := crossRootSets(findDuplicateSets(entries, getHasher(ff.HashAlgorithm)))
+	if len(sets) != 1 || len(sets[0].entries) != 2 || sets[0].entries[0].FileName != "a.txt" || sets[0].entries[1].FileName != "d.txt" {
+		t.Errorf("expected only a.txt and d.txt to be duplicated across roots, got %+v", sets)
+	}
+
+	ff.UniqueToRoot = inner
+	unique := findAndDisplayUniqueFiles(entries, ff)
+	if len(unique) != 1 || unique[0].FileName != "e.txt" {
+		t.Errorf("expected only e.txt to be unique to %s, got %+v", inner, unique)
+	}
+}
+
+// TestKeepIndex checks which file each keep policy keeps
+func TestKeepIndex(t *testing.T) {
+	now := time.Now()
+	set := []types.EntryResult{
+		{Directory: "/a/long/path", FileName: "f", ModTime: now},
+		{Directory: "/b", FileName: "f", ModTime: now.Add(-time.Hour)},
+		{Directory: "/c/mid", FileName: "f", ModTime: now.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		policy   types.KeepPolicy
+		prefixes []string
+		expected int
+	}{
+		{types.KeepPolicies.Oldest, nil, 1},
+		{types.KeepPolicies.Newest, nil, 2},
+		{types.KeepPolicies.ShortestPath, nil, 1},
+		{types.KeepPolicies.LongestPath, nil, 0},
+		{types.KeepPolicies.Preferred, []string{"/c", "/b"}, 2},
+		{types.KeepPolicies.First, nil, 0},
+	}
+
+	for _, tt := range tests {
+		if got := keepIndex(set, tt.policy, tt.prefixes, nil); got != tt.expected {
+			t.Errorf("keepIndex(%s) = %d, expected %d", tt.policy, got, tt.expected)
+		}
+	}
+}
+
+// TestHardlinkFile checks a verified duplicate is replaced by a link to the kept file
+func TestHardlinkFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	target := filepath.Join(dir, "target.txt")
+	for _, path := range []string{source, target} {
+		if err := os.WriteFile(path, []byte("same"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if outcome, message := hardlinkFile(source, target); outcome != types.ActionOutcomes.Done {
+		t.Fatalf("expected done, got %s: %s", outcome, message)
+	}
+
+	sourceInfo, _ := os.Stat(source)
+	targetInfo, _ := os.Stat(target)
+	if !os.SameFile(sourceInfo, targetInfo) {
+		t.Error("expected target to be a hard link to source")
+	}
+
+	if outcome, _ := hardlinkFile(source, target); outcome != types.ActionOutcomes.Skipped {
+		t.Errorf("expected already linked files to be skipped, got %s", outcome)
+	}
+}
+
+// TestConfirmDuplicateSets checks a set that only shared a colliding fast hash is split
+func TestConfirmDuplicateSets(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "same", "b.txt": "same", "c.txt": "diff"}
+
+	var set []types.EntryResult
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		set = append(set, types.EntryResult{Directory: dir, FileName: name, Size: 4})
+	}
+
+	sets := confirmDuplicateSets([]duplicateSet{{hash: "collision", entries: set}})
+	if len(sets) != 1 || len(sets[0].entries) != 2 || sets[0].entries[0].FileName != "a.txt" || sets[0].entries[1].FileName != "b.txt" {
+		t.Errorf("expected only a.txt and b.txt to stay a set, got %+v", sets)
+	}
+
+	if !collisionProne(types.HashAlgorithms.CRC32) || collisionProne(types.HashAlgorithms.SHA256) {
+		t.Error("expected only the fast hashes to need confirming")
+	}
+}
+
+// TestHashEntriesStoredHash checks a hash stored in the index is reused until the file changes
+func TestHashEntriesStoredHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher := getHasher(types.HashAlgorithms.SHA256)
+	entry := types.EntryResult{Directory: dir, FileName: "a.txt", Size: info.Size(), ModTime: info.ModTime(), Hash: "stored", HashAlgorithm: hasher.Algorithm()}
+	if hashes := hashEntries([]types.EntryResult{entry}, hasher); hashes[0] != "stored" {
+		t.Errorf("expected the stored hash to be reused, got %s", hashes[0])
+	}
+
+	if hashes := hashEntries([]types.EntryResult{entry}, getHasher(types.HashAlgorithms.MD5)); hashes[0] == "stored" {
+		t.Error("expected a hash stored with another algorithm to be ignored")
+	}
+
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := hashFile(hasher, path)
+	if hashes := hashEntries([]types.EntryResult{entry}, hasher); hashes[0] != expected {
+		t.Errorf("expected a changed file to be rehashed, got %s", hashes[0])
+	}
+}
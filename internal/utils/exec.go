@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// batchPlaceholder expands to every matched path in --exec-batch mode
+	batchPlaceholder = "{}+"
+	// maxBatchSize caps the number of paths passed to a single batched command
+	maxBatchSize = 512
+)
+
+// ExecuteCommands runs --exec once per found file or --exec-batch over all of
+// them, then renders a summary of the failed commands
+func ExecuteCommands(results interface{}, ff types.FileFinder) {
+	entries, err := toEntryResults(results)
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	var commands [][]string
+	if ff.ExecBatchCommand != "" {
+		commands, err = buildBatchCommands(ff.ExecBatchCommand, entryPaths(entries))
+	} else {
+		commands, err = buildCommands(ff.ExecCommand, entryPaths(entries))
+	}
+	if err != nil {
+		pterm.Error.Printf("error parsing command: %v\n", err)
+		return
+	}
+
+	var execResults []types.ExecResult
+	if ff.ExecBatchCommand != "" {
+		// Batches run one after another like find -exec {} +, so commands
+		// writing a single output such as an archive do not clobber each other
+		execResults = runCommandsInOrder(commands)
+	} else {
+		execResults = runCommands(commands, ff.ExecJobs)
+	}
+	renderExecResultsToTable(execResults)
+}
+
+func entryPaths(entries []types.EntryResult) []string {
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(entry.Directory, entry.FileName))
+	}
+	return paths
+}
+
+// buildCommands expands the template once per path
+func buildCommands(template string, paths []string) ([][]string, error) {
+	args, err := splitCommandLine(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+
+	hasPlaceholder := false
+	for _, arg := range args {
+		if expandPlaceholders(arg, "") != arg {
+			hasPlaceholder = true
+		}
+	}
+
+	commands := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		command := make([]string, 0, len(args)+1)
+		for _, arg := range args {
+			command = append(command, expandPlaceholders(arg, path))
+		}
+		if !hasPlaceholder {
+			command = append(command, path)
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// buildBatchCommands expands the {}+ placeholder to as many paths as fit in a
+// batch, appending them when the template has no placeholder
+func buildBatchCommands(template string, paths []string) ([][]string, error) {
+	args, err := splitCommandLine(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+
+	placeholderIndex := -1
+	for i, arg := range args {
+		if arg == batchPlaceholder || arg == "{}" {
+			placeholderIndex = i
+			break
+		}
+	}
+
+	var commands [][]string
+	for start := 0; start < len(paths); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		batch := paths[start:end]
+
+		var command []string
+		if placeholderIndex < 0 {
+			command = append(append(command, args...), batch...)
+		} else {
+			command = append(command, args[:placeholderIndex]...)
+			command = append(command, batch...)
+			command = append(command, args[placeholderIndex+1:]...)
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// expandPlaceholders replaces {} / {path}, {dir}, {base} and {ext} in arg,
+// leaving arg untouched when it has none of them
+func expandPlaceholders(arg, path string) string {
+	replacer := strings.NewReplacer(
+		"{path}", path,
+		"{dir}", filepath.Dir(path),
+		"{base}", filepath.Base(path),
+		"{ext}", strings.TrimPrefix(filepath.Ext(path), "."),
+		"{}", path,
+	)
+	return replacer.Replace(arg)
+}
+
+// splitCommandLine splits a command line into arguments, honouring single and
+// double quotes and backslash escapes
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'' && runtime.GOOS != "windows":
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// runCommands runs the commands with at most jobs running at once, printing
+// each command's output as it completes
+func runCommands(commands [][]string, jobs int) []types.ExecResult {
+	limiter := newCommandLimiter(jobs)
+
+	var wg sync.WaitGroup
+	execResults := make([]types.ExecResult, len(commands))
+	for i, command := range commands {
+		wg.Add(1)
+		go func(i int, command []string) {
+			defer wg.Done()
+			execResults[i] = limiter.run(command)
+		}(i, command)
+	}
+
+	wg.Wait()
+	return execResults
+}
+
+// runCommandsInOrder runs the commands one at a time in the order given
+func runCommandsInOrder(commands [][]string) []types.ExecResult {
+	limiter := newCommandLimiter(1)
+
+	execResults := make([]types.ExecResult, 0, len(commands))
+	for _, command := range commands {
+		execResults = append(execResults, limiter.run(command))
+	}
+	return execResults
+}
+
+// commandLimiter runs commands with at most a fixed number running at once,
+// it is shared by every command started through it
+type commandLimiter struct {
+	slots chan struct{}
+	// mu keeps the output of commands finishing together from interleaving
+	mu sync.Mutex
+}
+
+// newCommandLimiter returns a limiter running jobs commands at once, one per CPU when jobs is below 1
+func newCommandLimiter(jobs int) *commandLimiter {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	return &commandLimiter{slots: make(chan struct{}, jobs)}
+}
+
+// run waits for a free slot, runs command and prints its output once it completes
+func (l *commandLimiter) run(command []string) types.ExecResult {
+	l.slots <- struct{}{}
+	defer func() { <-l.slots }()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	result := types.ExecResult{Command: strings.Join(command, " ")}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Message = lastLine(stderr.String())
+	case err != nil:
+		result.ExitCode = -1
+		result.Message = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	os.Stdout.Write(stdout.Bytes())
+	os.Stderr.Write(stderr.Bytes())
+	return result
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func renderExecResultsToTable(execResults []types.ExecResult) {
+	var failed []types.ExecResult
+	for _, result := range execResults {
+		if result.ExitCode != 0 {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(failed) == 0 {
+		pterm.Success.Printf("%d commands completed successfully\n", len(execResults))
+		return
+	}
+
+	t := table.Table{}
+	t.AppendHeader(table.Row{"Command", "Exit Code", "Error"})
+	for _, result := range failed {
+		t.AppendRow(table.Row{result.Command, pterm.Sprintf("%v", result.ExitCode), result.Message})
+	}
+	t.AppendFooter(table.Row{
+		"Failed",
+		pterm.Sprintf("%v", len(failed)),
+		pterm.Sprintf("%d of %d commands succeeded", len(execResults)-len(failed), len(execResults)),
+	})
+
+	renderTable(&t)
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestSplitCommandLine checks quoting and escaping rules
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{"echo {}", []string{"echo", "{}"}, false},
+		{`mv "{}" '/tmp/some dir'`, []string{"mv", "{}", "/tmp/some dir"}, false},
+		{`echo a\ b`, []string{"echo", "a b"}, false},
+		{`echo "unterminated`, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitCommandLine(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("splitCommandLine(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestBuildCommands checks placeholder expansion for single and batched commands
+func TestBuildCommands(t *testing.T) {
+	paths := []string{"dir/a.txt", "dir/b.jpg"}
+
+	commands, err := buildCommands("echo {base} {ext} {dir}", paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"echo", "a.txt", "txt", "dir"}, {"echo", "b.jpg", "jpg", "dir"}}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %q, got %q", expected, commands)
+	}
+
+	commands, err = buildCommands("rm", paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = [][]string{{"rm", "dir/a.txt"}, {"rm", "dir/b.jpg"}}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %q, got %q", expected, commands)
+	}
+
+	commands, err = buildBatchCommands("tar -cf out.tar {}+ extra", paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = [][]string{{"tar", "-cf", "out.tar", "dir/a.txt", "dir/b.jpg", "extra"}}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %q, got %q", expected, commands)
+	}
+}
+
+// TestRunCommandsInOrder checks batches run one after another in their order
+func TestRunCommandsInOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	output := filepath.Join(t.TempDir(), "output.txt")
+	var commands [][]string
+	var expected strings.Builder
+	for i := 0; i < 10; i++ {
+		commands = append(commands, []string{"sh", "-c", fmt.Sprintf("echo %d >> %s", i, output)})
+		fmt.Fprintf(&expected, "%d\n", i)
+	}
+
+	for _, result := range runCommandsInOrder(commands) {
+		if result.ExitCode != 0 {
+			t.Fatalf("command %s failed: %s", result.Command, result.Message)
+		}
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected.String() {
+		t.Errorf("expected the commands to run in order, got %q", content)
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+
+	"file-finder/internal/types"
+
+	"github.com/pterm/pterm"
+)
+
+// HardlinkDuplicates replaces every duplicate marked for removal with a hard
+// link to the file kept from its set
+func HardlinkDuplicates(results interface{}) {
+	duplicates, ok := results.([]types.DuplicateResult)
+	if !ok {
+		pterm.Error.Printf("invalid data format: expected []DuplicateResult, got %T\n", results)
+		return
+	}
+
+	if len(duplicates) == 0 {
+		return
+	}
+
+	result, _ := pterm.DefaultInteractiveConfirm.Show("Are you sure you want to replace these duplicates with hard links?")
+	if !result {
+		pterm.Info.Println("Hard linking cancelled.")
+		return
+	}
+
+	renderActionResultsToTable(hardlinkDuplicateResults(duplicates), types.ActionTypes.Hardlink)
+}
+
+func hardlinkDuplicateResults(duplicates []types.DuplicateResult) []types.ActionResult {
+	kept := make(map[int]string)
+	for _, duplicate := range duplicates {
+		if duplicate.Status == types.DuplicateStatuses.Keep {
+			kept[duplicate.Set] = entryPath(duplicate.Entry)
+		}
+	}
+
+	var actionResults []types.ActionResult
+	for _, duplicate := range duplicates {
+		if duplicate.Status != types.DuplicateStatuses.Remove {
+			continue
+		}
+
+		target := entryPath(duplicate.Entry)
+		source, ok := kept[duplicate.Set]
+		if !ok {
+			actionResults = append(actionResults, failedActionResult(target, "", fmt.Errorf("no kept file for set %d", duplicate.Set)))
+			continue
+		}
+
+		outcome, message := hardlinkFile(source, target)
+		actionResults = append(actionResults, types.ActionResult{
+			Source:      target,
+			Destination: source,
+			Outcome:     outcome,
+			Message:     message,
+		})
+	}
+	return actionResults
+}
+
+// hardlinkFile replaces target with a hard link to source once both are
+// confirmed to be on the same device and to still have identical content. The
+// link is created under a temporary name and renamed over target so target
+// always exists.
+func hardlinkFile(source, target string) (types.ActionOutcome, string) {
+	same, err := sameDevice(source, target)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	if !same {
+		return types.ActionOutcomes.Skipped, "cross-device"
+	}
+
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	targetInfo, err := os.Stat(target)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	if os.SameFile(sourceInfo, targetInfo) {
+		return types.ActionOutcomes.Skipped, "already linked"
+	}
+
+	// Always re-checked with SHA-256, the duplicates may have been found with a
+	// fast hash that can collide
+	hasher := getHasher(types.HashAlgorithms.SHA256)
+	sourceHash, err := hashFile(hasher, source)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	targetHash, err := hashFile(hasher, target)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	if sourceHash != targetHash {
+		return types.ActionOutcomes.Skipped, "content changed"
+	}
+
+	tmp := nextAvailablePath(target + ".ff-link")
+	if err := os.Link(source, tmp); err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+
+	return types.ActionOutcomes.Done, ""
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"file-finder/internal/types"
+
+	"github.com/pterm/pterm"
+)
+
+// Hasher computes file content hashes with a single algorithm
+type Hasher interface {
+	// Algorithm returns the name stored alongside every hash
+	Algorithm() types.HashAlgorithm
+	// New returns a hash.Hash ready to be written to
+	New() hash.Hash
+}
+
+// stdHasher is a Hasher backed by a hash from the standard library
+type stdHasher struct {
+	algorithm types.HashAlgorithm
+	newHash   func() hash.Hash
+}
+
+func (h stdHasher) Algorithm() types.HashAlgorithm {
+	return h.algorithm
+}
+
+func (h stdHasher) New() hash.Hash {
+	return h.newHash()
+}
+
+// hashers maps each hash algorithm to its Hasher
+var hashers = map[types.HashAlgorithm]Hasher{
+	types.HashAlgorithms.CRC32:  stdHasher{types.HashAlgorithms.CRC32, func() hash.Hash { return crc32.NewIEEE() }},
+	types.HashAlgorithms.FNV64:  stdHasher{types.HashAlgorithms.FNV64, func() hash.Hash { return fnv.New64a() }},
+	types.HashAlgorithms.MD5:    stdHasher{types.HashAlgorithms.MD5, md5.New},
+	types.HashAlgorithms.SHA1:   stdHasher{types.HashAlgorithms.SHA1, sha1.New},
+	types.HashAlgorithms.SHA256: stdHasher{types.HashAlgorithms.SHA256, sha256.New},
+}
+
+// ToHashAlgorithm converts a string to a HashAlgorithm, returning "" when unknown
+func ToHashAlgorithm(algorithm string) types.HashAlgorithm {
+	for _, a := range []types.HashAlgorithm{types.HashAlgorithms.CRC32, types.HashAlgorithms.FNV64, types.HashAlgorithms.MD5, types.HashAlgorithms.SHA1, types.HashAlgorithms.SHA256} {
+		if strings.EqualFold(algorithm, string(a)) {
+			return a
+		}
+	}
+	return ""
+}
+
+// collisionProne reports whether files with different content are likely
+// enough to share a hash of algorithm that duplicates must be confirmed
+func collisionProne(algorithm types.HashAlgorithm) bool {
+	return algorithm == types.HashAlgorithms.CRC32 || algorithm == types.HashAlgorithms.FNV64
+}
+
+// getHasher returns the Hasher for algorithm, SHA-256 when none was chosen
+func getHasher(algorithm types.HashAlgorithm) Hasher {
+	if hasher, ok := hashers[algorithm]; ok {
+		return hasher
+	}
+	return hashers[types.HashAlgorithms.SHA256]
+}
+
+// hashFile returns the hex encoded hash of the file at path
+func hashFile(hasher Hasher, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := hasher.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashPaths hashes the files using one worker per CPU, unreadable files get an empty hash
+func hashPaths(hasher Hasher, paths []string) []string {
+	var wg sync.WaitGroup
+	hashes := make([]string, len(paths))
+	limit := make(chan struct{}, runtime.NumCPU())
+	for i, path := range paths {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			hash, err := hashFile(hasher, path)
+			if err != nil {
+				pterm.Error.Printf("Error hashing %s: %v\n", path, err)
+				return
+			}
+			hashes[i] = hash
+		}(i, path)
+	}
+	wg.Wait()
+
+	return hashes
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestHashFile checks every registered hasher against a known digest
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abc.txt")
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		algorithm types.HashAlgorithm
+		expected  string
+	}{
+		{types.HashAlgorithms.CRC32, "352441c2"},
+		{types.HashAlgorithms.FNV64, "e71fa2190541574b"},
+		{types.HashAlgorithms.MD5, "900150983cd24fb0d6963f7d28e17f72"},
+		{types.HashAlgorithms.SHA1, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{types.HashAlgorithms.SHA256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+
+	if len(tests) != len(hashers) {
+		t.Fatalf("expected a test for each of the %d hashers", len(hashers))
+	}
+
+	for _, tt := range tests {
+		hasher := getHasher(ToHashAlgorithm(string(tt.algorithm)))
+		if hasher.Algorithm() != tt.algorithm {
+			t.Errorf("getHasher(%s) returned the %s hasher", tt.algorithm, hasher.Algorithm())
+			continue
+		}
+
+		hash, err := hashFile(hasher, path)
+		if err != nil || hash != tt.expected {
+			t.Errorf("hashFile(%s) = %q, %v, expected %q", tt.algorithm, hash, err, tt.expected)
+		}
+	}
+}
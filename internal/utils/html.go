@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// htmlBreakdownLimit is the number of extensions charted before the rest are
+// combined into a single bar
+const htmlBreakdownLimit = 10
+
+// htmlReport is the data rendered by htmlReportTemplate
+type htmlReport struct {
+	GeneratedAt   string
+	Roots         []string
+	ShowRoot      bool
+	TotalCount    int
+	TotalSize     string
+	Files         []htmlFileRow
+	Directories   []htmlDirectoryRow
+	Duplicates    []htmlDuplicateRow
+	DuplicateSets int
+	Reclaimable   string
+	Breakdown     []htmlBreakdownRow
+}
+
+type htmlFileRow struct {
+	Root          string
+	Directory     string
+	DirectoryLink template.URL
+	Name          string
+	Link          template.URL
+	Size          string
+	Bytes         int64
+	ModTime       string
+	ModTimeUnix   int64
+}
+
+type htmlDirectoryRow struct {
+	Directory string
+	Link      template.URL
+	Count     int
+}
+
+type htmlDuplicateRow struct {
+	Set    int
+	Hash   string
+	Status string
+	htmlFileRow
+}
+
+type htmlBreakdownRow struct {
+	Name    string
+	Count   int
+	Size    string
+	Percent float64
+}
+
+// renderResultsToHTML writes the found files to a standalone HTML report
+func renderResultsToHTML(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder) {
+	report := newHTMLReport(ff)
+	report.TotalCount = totalCount
+	report.TotalSize = commonFormatters.FormatSize(totalFileSize)
+
+	switch results := results.(type) {
+	case []types.EntryResult:
+		for _, entry := range results {
+			report.Files = append(report.Files, newHTMLFileRow(entry))
+		}
+		report.Breakdown = htmlBreakdown(results)
+	case []types.DirectoryResult:
+		for _, result := range results {
+			report.Directories = append(report.Directories, htmlDirectoryRow{
+				Directory: result.Directory,
+				Link:      fileURL(result.Directory),
+				Count:     result.Count,
+			})
+		}
+	}
+
+	if err := writeHTMLReport(ff.ReportFile, report); err != nil {
+		pterm.Error.Printf("error writing report: %v\n", err)
+		return
+	}
+	pterm.Success.Printf("Wrote report of %d files to %s\n", totalCount, ff.ReportFile)
+}
+
+// renderDuplicatesToHTML writes the duplicate sets to a standalone HTML report
+func renderDuplicatesToHTML(duplicates []types.DuplicateResult, setCount int, ff types.FileFinder) {
+	report := newHTMLReport(ff)
+	report.DuplicateSets = setCount
+	report.Reclaimable = commonFormatters.FormatSize(reclaimableSize(duplicates))
+
+	var totalFileSize int64
+	entries := make([]types.EntryResult, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		report.Duplicates = append(report.Duplicates, htmlDuplicateRow{
+			Set:         duplicate.Set,
+			Hash:        duplicate.Hash,
+			Status:      string(duplicate.Status),
+			htmlFileRow: newHTMLFileRow(duplicate.Entry),
+		})
+		entries = append(entries, duplicate.Entry)
+		totalFileSize += duplicate.Entry.Size
+	}
+	report.TotalCount = len(duplicates)
+	report.TotalSize = commonFormatters.FormatSize(totalFileSize)
+	report.Breakdown = htmlBreakdown(entries)
+
+	if err := writeHTMLReport(ff.ReportFile, report); err != nil {
+		pterm.Error.Printf("error writing report: %v\n", err)
+		return
+	}
+	pterm.Success.Printf("Wrote report of %d duplicate sets to %s\n", setCount, ff.ReportFile)
+}
+
+func newHTMLReport(ff types.FileFinder) htmlReport {
+	roots := ff.RootDirectories
+	if len(roots) == 0 {
+		roots = []string{ff.RootDirectory}
+	}
+	return htmlReport{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Roots:       roots,
+		ShowRoot:    len(roots) > 1,
+	}
+}
+
+func newHTMLFileRow(entry types.EntryResult) htmlFileRow {
+	return htmlFileRow{
+		Root:          entry.Root,
+		Directory:     entry.Directory,
+		DirectoryLink: fileURL(entry.Directory),
+		Name:          entry.FileName,
+		Link:          fileURL(entryPath(entry)),
+		Size:          commonFormatters.FormatSize(entry.Size),
+		Bytes:         entry.Size,
+		ModTime:       entry.ModTime.Format("2006-01-02 15:04:05"),
+		ModTimeUnix:   entry.ModTime.Unix(),
+	}
+}
+
+// htmlBreakdown charts the size per extension, largest first
+func htmlBreakdown(entries []types.EntryResult) []htmlBreakdownRow {
+	stats := collectStats(entries, time.Now())
+	if stats.TotalSize == 0 {
+		return nil
+	}
+
+	buckets := stats.Extensions
+	if len(buckets) > htmlBreakdownLimit {
+		others := types.StatsBucket{Name: "(others)"}
+		for _, bucket := range buckets[htmlBreakdownLimit-1:] {
+			others.Count += bucket.Count
+			others.Size += bucket.Size
+		}
+		buckets = append(buckets[:htmlBreakdownLimit-1:htmlBreakdownLimit-1], others)
+	}
+
+	rows := make([]htmlBreakdownRow, 0, len(buckets))
+	for _, bucket := range buckets {
+		rows = append(rows, htmlBreakdownRow{
+			Name:    bucket.Name,
+			Count:   bucket.Count,
+			Size:    commonFormatters.FormatSize(bucket.Size),
+			Percent: float64(bucket.Size) * 100 / float64(stats.TotalSize),
+		})
+	}
+	return rows
+}
+
+// fileURL returns a file:// link to path. html/template only trusts http and
+// mailto links, the link is built from a local path so it is marked as safe.
+func fileURL(path string) template.URL {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if filepath.VolumeName(path) != "" {
+		// Windows paths like C:/dir need a leading slash after file://
+		path = "/" + path
+	}
+	return template.URL((&url.URL{Scheme: "file", Path: path}).String())
+}
+
+func writeHTMLReport(file string, report htmlReport) error {
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := htmlReportTemplate.Execute(f, report); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// htmlReportTemplate is a self-contained page, the styles and scripts are
+// inline so the report can be mailed or opened without network access
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"even": func(i int) bool { return i%2 == 0 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>File Finder Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem; color: #222; }
+h1 { margin-bottom: 0.2rem; }
+.meta { color: #666; margin-bottom: 1.5rem; }
+.totals { display: flex; gap: 1rem; margin-bottom: 1.5rem; }
+.totals div { background: #f3f5f8; border-radius: 6px; padding: 0.8rem 1.2rem; }
+.totals strong { display: block; font-size: 1.4rem; }
+.chart { max-width: 50rem; margin-bottom: 1.5rem; }
+.bar { display: flex; align-items: center; gap: 0.5rem; margin: 0.2rem 0; }
+.bar span { width: 8rem; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.bar .track { flex: 1; }
+.bar .fill { background: #4c8bf5; height: 1rem; border-radius: 3px; min-width: 2px; }
+.bar em { color: #666; font-style: normal; white-space: nowrap; }
+input.filter { padding: 0.4rem; width: 20rem; margin-bottom: 0.5rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.3rem 0.6rem; border-bottom: 1px solid #e3e6ea; }
+th { background: #f3f5f8; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+td.number { text-align: right; }
+tr.set-even td { background: #fafbfc; }
+.KEEP { color: #1a7f37; font-weight: bold; }
+.REMOVE { color: #cf222e; font-weight: bold; }
+a { color: #0b5cad; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>File Finder Report</h1>
+<div class="meta">Generated {{.GeneratedAt}} for {{range $i, $root := .Roots}}{{if $i}}, {{end}}{{$root}}{{end}}</div>
+
+<div class="totals">
+<div><strong>{{.TotalCount}}</strong>files</div>
+<div><strong>{{.TotalSize}}</strong>total size</div>
+{{- if .Duplicates}}
+<div><strong>{{.DuplicateSets}}</strong>duplicate sets</div>
+<div><strong>{{.Reclaimable}}</strong>reclaimable</div>
+{{- end}}
+</div>
+
+{{- if .Breakdown}}
+<h2>Size by extension</h2>
+<div class="chart">
+{{- range .Breakdown}}
+<div class="bar"><span title="{{.Name}}">{{.Name}}</span><div class="track"><div class="fill" style="width: {{printf "%.1f" .Percent}}%"></div></div><em>{{.Size}} · {{.Count}} files · {{printf "%.1f" .Percent}}%</em></div>
+{{- end}}
+</div>
+{{- end}}
+
+{{- if .Duplicates}}
+<h2>Duplicate sets</h2>
+<input class="filter" type="search" placeholder="Filter duplicates" data-table="duplicates">
+<table id="duplicates">
+<thead><tr><th data-type="number">Set</th>{{if $.ShowRoot}}<th>Root</th>{{end}}<th>Directory</th><th>File Name</th><th data-type="number">Size</th><th>Status</th><th>Hash</th></tr></thead>
+<tbody>
+{{- range .Duplicates}}
+<tr class="{{if even .Set}}set-even{{end}}"><td class="number" data-value="{{.Set}}">{{.Set}}</td>{{if $.ShowRoot}}<td>{{.Root}}</td>{{end}}<td><a href="{{.DirectoryLink}}">{{.Directory}}</a></td><td><a href="{{.Link}}">{{.Name}}</a></td><td class="number" data-value="{{.Bytes}}">{{.Size}}</td><td class="{{.Status}}">{{.Status}}</td><td><code>{{.Hash}}</code></td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .Files}}
+<h2>Files</h2>
+<input class="filter" type="search" placeholder="Filter files" data-table="files">
+<table id="files">
+<thead><tr>{{if $.ShowRoot}}<th>Root</th>{{end}}<th>Directory</th><th>File Name</th><th data-type="number">Size</th><th data-type="number">Modified</th></tr></thead>
+<tbody>
+{{- range .Files}}
+<tr>{{if $.ShowRoot}}<td>{{.Root}}</td>{{end}}<td><a href="{{.DirectoryLink}}">{{.Directory}}</a></td><td><a href="{{.Link}}">{{.Name}}</a></td><td class="number" data-value="{{.Bytes}}">{{.Size}}</td><td data-value="{{.ModTimeUnix}}">{{.ModTime}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .Directories}}
+<h2>Directories</h2>
+<input class="filter" type="search" placeholder="Filter directories" data-table="directories">
+<table id="directories">
+<thead><tr><th>Directory</th><th data-type="number">Count</th></tr></thead>
+<tbody>
+{{- range .Directories}}
+<tr><td><a href="{{.Link}}">{{.Directory}}</a></td><td class="number" data-value="{{.Count}}">{{.Count}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+<script>
+document.querySelectorAll("input.filter").forEach(function (input) {
+  input.addEventListener("input", function () {
+    var query = input.value.toLowerCase();
+    document.querySelectorAll("#" + input.dataset.table + " tbody tr").forEach(function (row) {
+      row.style.display = row.textContent.toLowerCase().indexOf(query) === -1 ? "none" : "";
+    });
+  });
+});
+
+document.querySelectorAll("th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table");
+    var index = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var numeric = th.dataset.type === "number";
+    var ascending = !th.classList.contains("asc");
+    table.querySelectorAll("th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+    th.classList.add(ascending ? "asc" : "desc");
+
+    var body = table.tBodies[0];
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[index], y = b.cells[index];
+      var vx = x.dataset.value !== undefined ? x.dataset.value : x.textContent;
+      var vy = y.dataset.value !== undefined ? y.dataset.value : y.textContent;
+      var c = numeric ? vx - vy : vx.localeCompare(vy);
+      return ascending ? c : -c;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestHTMLReport checks the report escapes names, links files and combines small extensions in the chart
+func TestHTMLReport(t *testing.T) {
+	root := t.TempDir()
+	var entries []types.EntryResult
+	for i := 0; i < htmlBreakdownLimit+2; i++ {
+		entries = append(entries, types.EntryResult{Root: root, Directory: root, FileName: fmt.Sprintf("file%d.ext%d", i, i), Size: int64(100 - i)})
+	}
+	entries = append(entries, types.EntryResult{Root: root, Directory: root, FileName: "<script>.txt", Size: 1})
+
+	report := newHTMLReport(types.FileFinder{RootDirectory: root})
+	for _, entry := range entries {
+		report.Files = append(report.Files, newHTMLFileRow(entry))
+	}
+	report.Breakdown = htmlBreakdown(entries)
+
+	if len(report.Breakdown) != htmlBreakdownLimit || report.Breakdown[htmlBreakdownLimit-1].Name != "(others)" {
+		t.Errorf("expected %d bars ending with (others), got %+v", htmlBreakdownLimit, report.Breakdown)
+	}
+
+	file := filepath.Join(root, "report.html")
+	if err := writeHTMLReport(file, report); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	if !strings.Contains(html, "&lt;script&gt;.txt") {
+		t.Error("expected file names to be escaped")
+	}
+	if link := fileURL(filepath.Join(root, "file0.ext0")); !strings.HasPrefix(string(link), "file://") || !strings.Contains(html, `href="`+string(link)+`"`) {
+		t.Errorf("expected a file:// link to file0.ext0, got %s", link)
+	}
+	if strings.Contains(html, "http://") || strings.Contains(html, "https://") {
+		t.Error("expected the report not to reference the network")
+	}
+}
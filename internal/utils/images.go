@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoding
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"math/bits"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+// decodableImageExtensions are the extensions the standard library can decode
+var decodableImageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+}
+
+// ToImageHashAlgorithm converts a string to an ImageHashAlgorithm, returning "" when unknown
+func ToImageHashAlgorithm(algorithm string) types.ImageHashAlgorithm {
+	for _, a := range []types.ImageHashAlgorithm{types.ImageHashAlgorithms.Average, types.ImageHashAlgorithms.Difference} {
+		if strings.EqualFold(algorithm, string(a)) {
+			return a
+		}
+	}
+	return ""
+}
+
+// findAndDisplaySimilarImages hashes every decodable image, clusters the
+// images whose hashes are within ff.SimilarityDistance bits of each other and
+// renders the groups
+func findAndDisplaySimilarImages(entries []types.EntryResult, ff types.FileFinder) []types.SimilarImageResult {
+	var images []types.EntryResult
+	for _, entry := range entries {
+		if decodableImageExtensions[strings.ToLower(filepath.Ext(entry.FileName))] {
+			images = append(images, entry)
+		}
+	}
+
+	hashed := hashImages(images, ff.ImageHashAlgorithm)
+	clusters := clusterIndices(len(hashed), func(i, j int) bool {
+		return hammingDistance(hashed[i].Hash, hashed[j].Hash) <= ff.SimilarityDistance
+	})
+
+	similar := []types.SimilarImageResult{}
+	for group, cluster := range clusters {
+		first := hashed[cluster[0]]
+		for _, i := range cluster {
+			result := hashed[i]
+			result.Group = group + 1
+			result.Distance = hammingDistance(first.Hash, result.Hash)
+			similar = append(similar, result)
+		}
+	}
+
+	if len(similar) == 0 {
+		pterm.Info.Printf("0 similar images found among %d images matching criteria\n", len(hashed))
+		return similar
+	}
+
+	renderSimilarImagesToTable(similar, len(clusters))
+	return similar
+}
+
+// hashImages decodes and hashes the images using one worker per CPU, images
+// that cannot be decoded are left out
+func hashImages(entries []types.EntryResult, algorithm types.ImageHashAlgorithm) []types.SimilarImageResult {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	spinner := startSpinner(fmt.Sprintf("Hashing %d images...", len(entries)))
+	defer spinner.Stop()
+
+	var wg sync.WaitGroup
+	results := make([]*types.SimilarImageResult, len(entries))
+	limit := make(chan struct{}, runtime.NumCPU())
+	for i, entry := range entries {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, entry types.EntryResult) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			img, err := decodeImage(entryPath(entry))
+			if err != nil {
+				return
+			}
+
+			bounds := img.Bounds()
+			results[i] = &types.SimilarImageResult{
+				Entry:  entry,
+				Width:  bounds.Dx(),
+				Height: bounds.Dy(),
+				Hash:   perceptualHash(img, algorithm),
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	var hashed []types.SimilarImageResult
+	for _, result := range results {
+		if result != nil {
+			hashed = append(hashed, *result)
+		}
+	}
+	return hashed
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// perceptualHash returns a 64 bit hash of img that changes little when the
+// image is resized or re-encoded
+func perceptualHash(img image.Image, algorithm types.ImageHashAlgorithm) uint64 {
+	var hash uint64
+	if algorithm == types.ImageHashAlgorithms.Average {
+		// aHash: each bit is whether a pixel of an 8x8 thumbnail is brighter than the mean
+		pixels := grayscaleThumbnail(img, 8, 8)
+		var sum float64
+		for _, row := range pixels {
+			for _, p := range row {
+				sum += p
+			}
+		}
+		mean := sum / 64
+		for _, row := range pixels {
+			for _, p := range row {
+				hash <<= 1
+				if p > mean {
+					hash |= 1
+				}
+			}
+		}
+		return hash
+	}
+
+	// dHash: each bit is whether a pixel of a 9x8 thumbnail is brighter than its right neighbour
+	pixels := grayscaleThumbnail(img, 9, 8)
+	for _, row := range pixels {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if row[x] > row[x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// grayscaleThumbnail shrinks img to width x height luminance values by
+// averaging a bounded number of samples from each cell
+func grayscaleThumbnail(img image.Image, width, height int) [][]float64 {
+	const maxSamples = 8
+
+	bounds := img.Bounds()
+	cellWidth := float64(bounds.Dx()) / float64(width)
+	cellHeight := float64(bounds.Dy()) / float64(height)
+
+	pixels := make([][]float64, height)
+	for y := 0; y < height; y++ {
+		pixels[y] = make([]float64, width)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + int(float64(x)*cellWidth)
+			y0 := bounds.Min.Y + int(float64(y)*cellHeight)
+			x1 := bounds.Min.X + int(float64(x+1)*cellWidth)
+			y1 := bounds.Min.Y + int(float64(y+1)*cellHeight)
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			if y1 <= y0 {
+				y1 = y0 + 1
+			}
+			stepX := max(1, (x1-x0)/maxSamples)
+			stepY := max(1, (y1-y0)/maxSamples)
+
+			var sum float64
+			var count int
+			for sy := y0; sy < y1 && sy < bounds.Max.Y; sy += stepY {
+				for sx := x0; sx < x1 && sx < bounds.Max.X; sx += stepX {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				pixels[y][x] = sum / float64(count)
+			}
+		}
+	}
+	return pixels
+}
+
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func renderSimilarImagesToTable(similar []types.SimilarImageResult, groupCount int) {
+	t := table.Table{}
+	var totalFileSize int64
+	t.AppendHeader(table.Row{"Group", "Directory", "FileName", "Dimensions", "FileSize", "Distance"})
+	for _, result := range similar {
+		totalFileSize += result.Entry.Size
+		t.AppendRow(table.Row{
+			pterm.Sprintf("%v", result.Group),
+			formatResultHyperLink(result.Entry.Directory, result.Entry.Directory),
+			formatResultHyperLink(entryPath(result.Entry), result.Entry.FileName),
+			pterm.Sprintf("%dx%d", result.Width, result.Height),
+			result.Entry.FileSize,
+			pterm.Sprintf("%v", result.Distance),
+		})
+	}
+	t.AppendFooter(table.Row{
+		pterm.Sprintf("%v Groups", groupCount),
+		"Total",
+		pterm.Sprintf("%v", len(similar)),
+		"",
+		commonFormatters.FormatSize(totalFileSize),
+		"",
+	})
+
+	renderTable(&t)
+}
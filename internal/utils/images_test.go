@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+func gradientImage(width, height int, reverse bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if reverse {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// TestPerceptualHash checks resized copies hash alike while different images do not
+func TestPerceptualHash(t *testing.T) {
+	for _, algorithm := range []types.ImageHashAlgorithm{types.ImageHashAlgorithms.Average, types.ImageHashAlgorithms.Difference} {
+		original := perceptualHash(gradientImage(640, 480, false), algorithm)
+		resized := perceptualHash(gradientImage(160, 120, false), algorithm)
+		different := perceptualHash(gradientImage(640, 480, true), algorithm)
+
+		if d := hammingDistance(original, resized); d > 4 {
+			t.Errorf("%s: expected resized image to be within 4 bits, got %d", algorithm, d)
+		}
+		if d := hammingDistance(original, different); d < 20 {
+			t.Errorf("%s: expected different image to be at least 20 bits away, got %d", algorithm, d)
+		}
+	}
+}
+
+// TestClusterIndices checks related indices are grouped transitively
+func TestClusterIndices(t *testing.T) {
+	values := []int{1, 2, 10, 3, 20}
+	clusters := clusterIndices(len(values), func(i, j int) bool {
+		d := values[i] - values[j]
+		return d >= -1 && d <= 1
+	})
+
+	if len(clusters) != 1 {
+		t.Fatalf("expected one cluster, got %v", clusters)
+	}
+	if got := clusters[0]; len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 3 {
+		t.Errorf("expected cluster [0 1 3], got %v", got)
+	}
+}
@@ -0,0 +1,473 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonUtils "github.com/ondrovic/common/utils"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	indexRootsBucket       = "roots"
+	indexFilesBucketPrefix = "files:"
+	// indexDirectoriesBucketPrefix buckets hold the directory records used to
+	// skip unchanged directories when re-indexing
+	indexDirectoriesBucketPrefix = "dirs:"
+	indexOpenTimeout             = time.Second
+)
+
+// DefaultIndexPath returns the index file used when --index-file is not set
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "file-finder", "index.db")
+}
+
+// BuildIndex walks each root directory and stores every file's path, size,
+// modification time, mode and, when hashAlgorithm is set, hash in the index file, replacing
+// anything previously stored for that root. Unless full is set, directories
+// whose modification time and entry count did not change since the last run
+// are not re-read, only their files are stat'ed again, and hashes of files
+// whose size and modification time did not change are reused.
+func BuildIndex(roots []string, indexFile string, hashAlgorithm types.HashAlgorithm, full bool) error {
+	if err := os.MkdirAll(filepath.Dir(indexFile), 0o755); err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(indexFile, 0o600, &bolt.Options{Timeout: indexOpenTimeout})
+	if err != nil {
+		return fmt.Errorf("error opening index %s: %w", indexFile, err)
+	}
+	defer db.Close()
+
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+
+		previous := newIndexSnapshot()
+		if !full {
+			if previous, err = readIndexSnapshot(db, absRoot); err != nil {
+				return err
+			}
+		}
+
+		spinner := startSpinner(fmt.Sprintf("Indexing %s...", absRoot))
+		walker := &indexWalker{previous: previous, current: newIndexSnapshot()}
+		if err := walker.walk(absRoot); err != nil {
+			spinner.Fail(err.Error())
+			return err
+		}
+
+		if hashAlgorithm != "" {
+			spinner.UpdateText(fmt.Sprintf("Hashing files in %s...", absRoot))
+			walker.hashMissing(getHasher(hashAlgorithm))
+		}
+
+		meta := types.IndexMeta{
+			Root:          absRoot,
+			IndexedAt:     time.Now(),
+			FileCount:     len(walker.current.files),
+			HashAlgorithm: hashAlgorithm,
+		}
+		if err := writeIndex(db, meta, walker.current); err != nil {
+			spinner.Fail(err.Error())
+			return err
+		}
+		spinner.Success(fmt.Sprintf("Indexed %d files in %s", len(walker.current.files), absRoot))
+		renderIndexStatsToTable(walker.stats)
+	}
+
+	return nil
+}
+
+// indexSnapshot holds the files and directories stored for one root, the
+// lookups hold indexes into files
+type indexSnapshot struct {
+	files       []types.FileInfo
+	byPath      map[string]int
+	byDirectory map[string][]int
+	directories map[string]types.IndexedDirectory
+}
+
+func newIndexSnapshot() indexSnapshot {
+	return indexSnapshot{
+		byPath:      make(map[string]int),
+		byDirectory: make(map[string][]int),
+		directories: make(map[string]types.IndexedDirectory),
+	}
+}
+
+func (s *indexSnapshot) addFile(file types.FileInfo) {
+	s.byPath[file.Path] = len(s.files)
+	s.byDirectory[filepath.Dir(file.Path)] = append(s.byDirectory[filepath.Dir(file.Path)], len(s.files))
+	s.files = append(s.files, file)
+}
+
+func readIndexSnapshot(db *bolt.DB, root string) (indexSnapshot, error) {
+	snapshot := newIndexSnapshot()
+	err := db.View(func(tx *bolt.Tx) error {
+		if files := tx.Bucket([]byte(indexFilesBucketPrefix + root)); files != nil {
+			err := files.ForEach(func(_, v []byte) error {
+				var file types.FileInfo
+				if err := json.Unmarshal(v, &file); err != nil {
+					return err
+				}
+				// Hashes stored before the algorithm was recorded are all SHA-256
+				if file.Hash != "" && file.HashAlgorithm == "" {
+					file.HashAlgorithm = types.HashAlgorithms.SHA256
+				}
+				snapshot.addFile(file)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if directories := tx.Bucket([]byte(indexDirectoriesBucketPrefix + root)); directories != nil {
+			return directories.ForEach(func(_, v []byte) error {
+				var directory types.IndexedDirectory
+				if err := json.Unmarshal(v, &directory); err != nil {
+					return err
+				}
+				snapshot.directories[directory.Path] = directory
+				return nil
+			})
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// indexWalker builds the current snapshot of a root, reusing what it can
+// from the previous one
+type indexWalker struct {
+	previous indexSnapshot
+	current  indexSnapshot
+	stats    types.IndexStats
+}
+
+func (w *indexWalker) walk(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	names, err := readDirNames(dir)
+	if err != nil {
+		return err
+	}
+
+	if previous, ok := w.previous.directories[dir]; ok && previous.ModTime.Equal(info.ModTime()) && previous.EntryCount == len(names) {
+		w.stats.DirectoriesReused++
+		w.current.directories[dir] = previous
+		// Rewriting a file in place leaves its directory untouched, so the
+		// files themselves are still stat'ed
+		for _, i := range w.previous.byDirectory[dir] {
+			path := w.previous.files[i].Path
+			fileInfo, err := os.Lstat(path)
+			if err != nil || !fileInfo.Mode().IsRegular() {
+				continue
+			}
+			if w.addFile(path, fileInfo) {
+				w.stats.FilesReused++
+			} else {
+				w.stats.FilesRescanned++
+			}
+		}
+		for _, subdirectory := range previous.Subdirectories {
+			// Like in a rescan a subdirectory that became unreadable is left out
+			_ = w.walk(filepath.Join(dir, subdirectory))
+		}
+		return nil
+	}
+
+	w.stats.DirectoriesRescanned++
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	directory := types.IndexedDirectory{
+		Path:       dir,
+		ModTime:    info.ModTime(),
+		EntryCount: len(names),
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if err := w.walk(path); err == nil {
+				directory.Subdirectories = append(directory.Subdirectories, entry.Name())
+			}
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		entryInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		w.addFile(path, entryInfo)
+		w.stats.FilesRescanned++
+	}
+	w.current.directories[dir] = directory
+	return nil
+}
+
+// addFile adds the file at path to the current snapshot, keeping its previous
+// hash when its size and modification time did not change, which is reported
+func (w *indexWalker) addFile(path string, info os.FileInfo) bool {
+	file := types.FileInfo{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Mode:    info.Mode(),
+	}
+	unchanged := false
+	if i, ok := w.previous.byPath[path]; ok {
+		if previous := w.previous.files[i]; previous.Size == file.Size && previous.ModTime.Equal(file.ModTime) {
+			file.Hash = previous.Hash
+			file.HashAlgorithm = previous.HashAlgorithm
+			unchanged = true
+		}
+	}
+	w.current.addFile(file)
+	return unchanged
+}
+
+// hashMissing hashes every file of the current snapshot without a hash made by hasher
+func (w *indexWalker) hashMissing(hasher Hasher) {
+	var missing []int
+	var paths []string
+	for i, file := range w.current.files {
+		if file.Hash == "" || file.HashAlgorithm != hasher.Algorithm() {
+			missing = append(missing, i)
+			paths = append(paths, file.Path)
+		} else {
+			w.stats.HashesReused++
+		}
+	}
+
+	for i, hash := range hashPaths(hasher, paths) {
+		w.current.files[missing[i]].Hash = hash
+		w.current.files[missing[i]].HashAlgorithm = hasher.Algorithm()
+	}
+	w.stats.HashesComputed += len(paths)
+}
+
+// readDirNames returns the names in dir without stating them
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+func writeIndex(db *bolt.DB, meta types.IndexMeta, snapshot indexSnapshot) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		roots, err := tx.CreateBucketIfNotExists([]byte(indexRootsBucket))
+		if err != nil {
+			return err
+		}
+
+		files, err := recreateBucket(tx, indexFilesBucketPrefix+meta.Root)
+		if err != nil {
+			return err
+		}
+		for _, file := range snapshot.files {
+			if err := putJSON(files, file.Path, file); err != nil {
+				return err
+			}
+		}
+
+		directories, err := recreateBucket(tx, indexDirectoriesBucketPrefix+meta.Root)
+		if err != nil {
+			return err
+		}
+		for path, directory := range snapshot.directories {
+			if err := putJSON(directories, path, directory); err != nil {
+				return err
+			}
+		}
+
+		return putJSON(roots, meta.Root, meta)
+	})
+}
+
+func recreateBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	if tx.Bucket([]byte(name)) != nil {
+		if err := tx.DeleteBucket([]byte(name)); err != nil {
+			return nil, err
+		}
+	}
+	return tx.CreateBucket([]byte(name))
+}
+
+func putJSON(bucket *bolt.Bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), data)
+}
+
+func renderIndexStatsToTable(stats types.IndexStats) {
+	t := table.Table{}
+	t.AppendHeader(table.Row{"", "Reused", "Rescanned"})
+	t.AppendRow(table.Row{"Directories", pterm.Sprintf("%v", stats.DirectoriesReused), pterm.Sprintf("%v", stats.DirectoriesRescanned)})
+	t.AppendRow(table.Row{"Files", pterm.Sprintf("%v", stats.FilesReused), pterm.Sprintf("%v", stats.FilesRescanned)})
+	t.AppendRow(table.Row{"Hashes", pterm.Sprintf("%v", stats.HashesReused), pterm.Sprintf("%v", stats.HashesComputed)})
+
+	renderTable(&t)
+}
+
+// getFilesFromIndex answers the same query as getFiles from the index
+// instead of the file system. The root does not need to be indexed itself as
+// long as one of its parents is.
+func getFilesFromIndex(ff types.FileFinder) (interface{}, int, int64, error) {
+	indexFile := ff.IndexFile
+	if indexFile == "" {
+		indexFile = DefaultIndexPath()
+	}
+
+	if _, err := os.Stat(indexFile); err != nil {
+		return nil, 0, 0, fmt.Errorf("no index found at %s, run file-finder index first", indexFile)
+	}
+
+	db, err := bolt.Open(indexFile, 0o600, &bolt.Options{Timeout: indexOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error opening index %s: %w", indexFile, err)
+	}
+	defer db.Close()
+
+	absRoot, err := filepath.Abs(ff.RootDirectory)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	fileSize, err := convertFileSizeFilter(ff.FileSizeFilter)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	results := make(map[string][]string)
+	detailedResults := []types.EntryResult{}
+	var totalCount int
+	var totalFileSize int64
+
+	err = db.View(func(tx *bolt.Tx) error {
+		meta, err := findIndexedRoot(tx, absRoot)
+		if err != nil {
+			return err
+		}
+		pterm.Info.Printf("Using index of %s built %s ago\n", meta.Root, time.Since(meta.IndexedAt).Round(time.Second))
+
+		bucket := tx.Bucket([]byte(indexFilesBucketPrefix + meta.Root))
+		if bucket == nil {
+			return fmt.Errorf("index for %s is empty", meta.Root)
+		}
+
+		prefix := []byte(absRoot)
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && strings.HasPrefix(string(k), absRoot); k, v = cursor.Next() {
+			var file types.FileInfo
+			if err := json.Unmarshal(v, &file); err != nil {
+				return err
+			}
+			if !isBelow(absRoot, file.Path) || !matchesFileInfo(ff, file, fileSize) {
+				continue
+			}
+
+			// Report paths the same way a scan of ff.RootDirectory would
+			path := filepath.Join(ff.RootDirectory, relativeToRoot(absRoot, file.Path))
+			if ff.DisplayDetailedResults {
+				detailedResults = append(detailedResults, types.EntryResult{
+					Directory:     filepath.Dir(path),
+					FileName:      filepath.Base(path),
+					FileSize:      commonFormatters.FormatSize(file.Size),
+					Size:          file.Size,
+					ModTime:       file.ModTime,
+					Hash:          file.Hash,
+					HashAlgorithm: file.HashAlgorithm,
+				})
+				totalFileSize += file.Size
+			} else {
+				results[filepath.Dir(path)] = append(results[filepath.Dir(path)], path)
+			}
+			totalCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if ff.DisplayDetailedResults {
+		return detailedResults, totalCount, totalFileSize, nil
+	}
+	return results, totalCount, 0, nil
+}
+
+// findIndexedRoot returns the deepest indexed root containing root
+func findIndexedRoot(tx *bolt.Tx, root string) (types.IndexMeta, error) {
+	var found types.IndexMeta
+
+	roots := tx.Bucket([]byte(indexRootsBucket))
+	if roots != nil {
+		err := roots.ForEach(func(k, v []byte) error {
+			indexedRoot := string(k)
+			if !isBelow(indexedRoot, root) || len(indexedRoot) <= len(found.Root) {
+				return nil
+			}
+			return json.Unmarshal(v, &found)
+		})
+		if err != nil {
+			return found, err
+		}
+	}
+
+	if found.Root == "" {
+		return found, fmt.Errorf("%s is not indexed, run file-finder index %s first", root, root)
+	}
+	return found, nil
+}
+
+// isBelow reports whether path is root or inside it
+func isBelow(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// matchesFileInfo applies the same filters as processFile to an indexed or
+// watched file
+func matchesFileInfo(ff types.FileFinder, file types.FileInfo, fileSize int64) bool {
+	if !commonUtils.IsExtensionValid(ff.FileTypeFilter, file.Path) {
+		return false
+	}
+	if ff.FileSizeFilter != "" && !applyFileSizeFilter(ff, file.Size, fileSize) {
+		return false
+	}
+	if !applyFileNameFilter(ff, filepath.Base(file.Path)) {
+		return false
+	}
+	return applyModTimeFilter(ff, file.ModTime)
+}
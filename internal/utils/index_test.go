@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestIndexQuery checks a query answered from the index matches the indexed files
+func TestIndexQuery(t *testing.T) {
+	root := t.TempDir()
+	indexFile := filepath.Join(t.TempDir(), "index.db")
+
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(root, "a.txt"), filepath.Join(sub, "b.txt"), filepath.Join(sub, "c.jpg")} {
+		if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := BuildIndex([]string{root}, indexFile, "", false); err != nil {
+		t.Fatalf("BuildIndex returned error: %v", err)
+	}
+
+	ff := types.FileFinder{
+		DisplayDetailedResults: true,
+		FileNameFilter:         ".txt",
+		FileTypeFilter:         commonTypes.FileTypes.Any,
+		IndexFile:              indexFile,
+		RootDirectory:          sub,
+	}
+	results, count, _, err := getFilesFromIndex(ff)
+	if err != nil {
+		t.Fatalf("getFilesFromIndex returned error: %v", err)
+	}
+
+	entries := results.([]types.EntryResult)
+	if count != 1 || len(entries) != 1 || entries[0].FileName != "b.txt" || entries[0].Directory != sub {
+		t.Errorf("expected only %s/b.txt, got %+v", sub, entries)
+	}
+}
+
+// TestIndexWalkerReuse checks unchanged directories and hashes are reused when re-indexing
+func TestIndexWalkerReuse(t *testing.T) {
+	root := t.TempDir()
+	changed := filepath.Join(root, "changed")
+	unchanged := filepath.Join(root, "unchanged")
+	for _, dir := range []string{changed, unchanged} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := &indexWalker{previous: newIndexSnapshot(), current: newIndexSnapshot()}
+	if err := first.walk(root); err != nil {
+		t.Fatal(err)
+	}
+	first.hashMissing(getHasher(types.HashAlgorithms.SHA256))
+
+	if err := os.WriteFile(filepath.Join(changed, "new.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &indexWalker{previous: first.current, current: newIndexSnapshot()}
+	if err := second.walk(root); err != nil {
+		t.Fatal(err)
+	}
+	second.hashMissing(getHasher(types.HashAlgorithms.SHA256))
+
+	expected := types.IndexStats{
+		DirectoriesReused:    2,
+		DirectoriesRescanned: 1,
+		FilesReused:          1,
+		FilesRescanned:       2,
+		HashesReused:         2,
+		HashesComputed:       1,
+	}
+	if second.stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, second.stats)
+	}
+}
+
+// TestIndexWalkerRewrittenFile checks a file rewritten in place is picked up
+// even though its directory did not change
+func TestIndexWalkerRewrittenFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "file.txt")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &indexWalker{previous: newIndexSnapshot(), current: newIndexSnapshot()}
+	if err := first.walk(root); err != nil {
+		t.Fatal(err)
+	}
+	first.hashMissing(getHasher(types.HashAlgorithms.SHA256))
+
+	// Rewriting keeps the directory's modification time, which is pinned in
+	// case the file system updates it anyway
+	dirInfo, err := os.Stat(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("rewritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(root, dirInfo.ModTime(), dirInfo.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &indexWalker{previous: first.current, current: newIndexSnapshot()}
+	if err := second.walk(root); err != nil {
+		t.Fatal(err)
+	}
+	second.hashMissing(getHasher(types.HashAlgorithms.SHA256))
+
+	if second.stats.DirectoriesReused != 1 || second.stats.HashesComputed != 1 {
+		t.Errorf("expected the directory to be reused and the file rehashed, got %+v", second.stats)
+	}
+	file := second.current.files[second.current.byPath[path]]
+	expected, _ := hashFile(getHasher(types.HashAlgorithms.SHA256), path)
+	if file.Size != int64(len("rewritten")) || file.Hash != expected {
+		t.Errorf("expected the rewritten size and hash, got %+v", file)
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+)
+
+// renderResultsToMarkdown prints a summary of the scan followed by the results
+// table as Markdown, ready to paste into tickets and wiki pages
+func renderResultsToMarkdown(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder) {
+	fmt.Println(markdownSummary(totalCount, totalFileSize, ff))
+
+	// Paths are pasted elsewhere so they are written out instead of linked
+	t, ok := buildResultsTable(results, totalCount, totalFileSize, ff, func(_, txt string) string { return txt })
+	if !ok {
+		return
+	}
+	fmt.Printf("## Results\n\n%s\n", t.RenderMarkdown())
+}
+
+// markdownSummary lists the root directories, the filters that were set, how
+// long the scan took and the totals
+func markdownSummary(totalCount int, totalFileSize int64, ff types.FileFinder) string {
+	roots := ff.RootDirectories
+	if len(roots) == 0 {
+		roots = []string{ff.RootDirectory}
+	}
+	quoted := make([]string, 0, len(roots))
+	for _, root := range roots {
+		quoted = append(quoted, markdownCode(root))
+	}
+
+	items := [][2]string{
+		{"Roots", strings.Join(quoted, ", ")},
+		{"File type", string(ff.FileTypeFilter)},
+	}
+	if ff.FileNameFilter != "" {
+		items = append(items, [2]string{"File name contains", markdownCode(ff.FileNameFilter)})
+	}
+	if ff.FileSizeFilter != "" {
+		items = append(items, [2]string{"File size", fmt.Sprintf("%s %s (tolerance %g%%)", ff.OperatorTypeFilter, ff.FileSizeFilter, ff.ToleranceSize*100)})
+	}
+	if !ff.ModifiedAfter.IsZero() {
+		items = append(items, [2]string{"Modified after", ff.ModifiedAfter.Format("2006-01-02 15:04:05")})
+	}
+	if !ff.ModifiedBefore.IsZero() {
+		items = append(items, [2]string{"Modified before", ff.ModifiedBefore.Format("2006-01-02 15:04:05")})
+	}
+	if ff.ScanDuration > 0 {
+		duration := ff.ScanDuration
+		// Small scans keep their precision instead of rounding to 0s
+		if duration >= time.Millisecond {
+			duration = duration.Round(time.Millisecond)
+		}
+		items = append(items, [2]string{"Scan duration", duration.String()})
+	}
+	items = append(items, [2]string{"Files", fmt.Sprintf("%d", totalCount)})
+	if ff.DisplayDetailedResults {
+		items = append(items, [2]string{"Total size", commonFormatters.FormatSize(totalFileSize)})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Summary\n\n")
+	for _, item := range items {
+		fmt.Fprintf(&sb, "- **%s:** %s\n", item[0], item[1])
+	}
+	return sb.String()
+}
+
+// markdownCode wraps s in a code span, using a longer fence when s contains backticks
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestMarkdownSummary checks only the filters that were set are listed, with the totals
+func TestMarkdownSummary(t *testing.T) {
+	ff := types.FileFinder{
+		RootDirectories:        []string{"/a", "/b`c"},
+		FileTypeFilter:         commonTypes.FileTypes.Image,
+		FileNameFilter:         "holiday",
+		DisplayDetailedResults: true,
+		ScanDuration:           1500 * time.Millisecond,
+	}
+
+	summary := markdownSummary(3, 2048, ff)
+
+	expected := []string{
+		"- **Roots:** `/a`, ``/b`c``\n",
+		"- **File type:** Image\n",
+		"- **File name contains:** `holiday`\n",
+		"- **Scan duration:** 1.5s\n",
+		"- **Files:** 3\n",
+		"- **Total size:** 2.00 KB\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(summary, line) {
+			t.Errorf("expected %q in summary:\n%s", line, summary)
+		}
+	}
+
+	for _, unset := range []string{"File size", "Modified after", "Modified before"} {
+		if strings.Contains(summary, unset) {
+			t.Errorf("expected %s to be left out of summary:\n%s", unset, summary)
+		}
+	}
+}
+
+// TestBuildResultsTableMarkdown checks the markdown table has the same rows and footer as the terminal table
+func TestBuildResultsTableMarkdown(t *testing.T) {
+	entries := []types.EntryResult{{Directory: "/a", FileName: "x|y.txt", FileSize: "1.00 KB", Size: 1024}}
+	ff := types.FileFinder{DisplayDetailedResults: true}
+
+	tbl, ok := buildResultsTable(entries, 1, 1024, ff, func(_, txt string) string { return txt })
+	if !ok {
+		t.Fatal("expected a table for detailed results")
+	}
+
+	expected := "| Directory | FileName | FileSize |\n| --- | --- | --- |\n| /a | x\\|y.txt | 1.00 KB |\n| Total | 1 | 1.00 KB |"
+	if markdown := tbl.RenderMarkdown(); markdown != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, markdown)
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-finder/internal/types"
+)
+
+// moveFile moves src to dst, creating any missing parent directories. When a
+// plain rename is not possible because src and dst live on different devices
+// the file is copied, verified and then removed. The returned SHA-256 hash is
+// only set when a copy took place.
+func moveFile(src, dst string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	err := os.Rename(src, dst)
+	if err == nil {
+		return "", nil
+	}
+	if !isCrossDeviceError(err) {
+		return "", err
+	}
+
+	hash, err := copyAndVerify(src, dst)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(src); err != nil {
+		return hash, fmt.Errorf("copied %s but could not remove source: %w", src, err)
+	}
+	return hash, nil
+}
+
+// copyAndVerify copies src to dst preserving mode and modification time, then
+// re-reads dst and compares its hash with the one computed while copying
+func copyAndVerify(src, dst string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	hasher := getHasher(types.HashAlgorithms.SHA256)
+	h := hasher.New()
+	_, err = io.Copy(io.MultiWriter(out, h), in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	srcHash := hex.EncodeToString(h.Sum(nil))
+	dstHash, err := hashFile(hasher, dst)
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	if srcHash != dstHash {
+		os.Remove(dst)
+		return "", fmt.Errorf("verification failed copying %s to %s: hash mismatch", src, dst)
+	}
+
+	return srcHash, nil
+}
+
+// nextAvailablePath returns path unchanged if nothing exists there, otherwise
+// the first free variant with a numeric suffix before the extension
+func nextAvailablePath(path string) string {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
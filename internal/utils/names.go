@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+)
+
+var (
+	nameSeparators = regexp.MustCompile(`[\s_\-.]+`)
+	// nameCopyPrefix matches the "copy of" some file managers put in front of copies
+	nameCopyPrefix = regexp.MustCompile(`^copy of `)
+	// nameCopySuffix matches a copy marker such as "(1)", "copy", "copy 2" or
+	// "final" at the end of a name, in front of it they are part of the name
+	nameCopySuffix = regexp.MustCompile(` ?(\(\d+\)|\(copy\)|\bcopy( \d+)?|\bfinal|\bbackup|\bbak|\bold)$`)
+	nameNumbers    = regexp.MustCompile(`\d+`)
+)
+
+// findAndDisplaySimilarNames clusters the entries whose normalised names are
+// at least ff.NameSimilarity alike and renders the groups
+func findAndDisplaySimilarNames(entries []types.EntryResult, ff types.FileFinder) []types.SimilarNameResult {
+	// Compare each distinct normalised name once, files sharing one are always grouped
+	var names []string
+	byName := make(map[string][]types.EntryResult)
+	for _, entry := range entries {
+		name := normalizeFileName(entry.FileName)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], entry)
+	}
+
+	// Matches are not chained, so a run of sequential names does not collapse
+	// into one group through its neighbours. Only names starting with the same
+	// letter are compared, which keeps the number of comparisons down.
+	var clusters [][]int
+	for _, bucket := range nameBuckets(names) {
+		bucketClusters := clusterAroundFirst(len(bucket), func(i, j int) bool {
+			return similarNames(names[bucket[i]], names[bucket[j]], ff.NameSimilarity)
+		})
+		for _, bucketCluster := range bucketClusters {
+			cluster := make([]int, 0, len(bucketCluster))
+			for _, i := range bucketCluster {
+				cluster = append(cluster, bucket[i])
+			}
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+	for i, name := range names {
+		if len(byName[name]) > 1 && !inClusters(clusters, i) {
+			clusters = append(clusters, []int{i})
+		}
+	}
+
+	similar := []types.SimilarNameResult{}
+	for group, cluster := range clusters {
+		first := names[cluster[0]]
+		for _, i := range cluster {
+			for _, entry := range byName[names[i]] {
+				similar = append(similar, types.SimilarNameResult{
+					Group:          group + 1,
+					Entry:          entry,
+					NormalizedName: names[i],
+					Similarity:     nameSimilarity(first, names[i]),
+				})
+			}
+		}
+	}
+
+	if len(similar) == 0 {
+		pterm.Info.Println("0 files with similar names found matching criteria")
+		return similar
+	}
+
+	renderSimilarNamesToTable(similar, len(clusters))
+	return similar
+}
+
+func inClusters(clusters [][]int, index int) bool {
+	for _, cluster := range clusters {
+		for _, i := range cluster {
+			if i == index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameBuckets splits the indices of names by the first letter of the name,
+// in order of first appearance
+func nameBuckets(names []string) [][]int {
+	var buckets [][]int
+	byLetter := make(map[rune]int)
+	for i, name := range names {
+		letter, _ := utf8.DecodeRuneInString(name)
+		bucket, ok := byLetter[letter]
+		if !ok {
+			bucket = len(buckets)
+			byLetter[letter] = bucket
+			buckets = append(buckets, nil)
+		}
+		buckets[bucket] = append(buckets[bucket], i)
+	}
+	return buckets
+}
+
+// normalizeFileName lower-cases the name, drops its extension and copy
+// markers and collapses separators to single spaces
+func normalizeFileName(fileName string) string {
+	name := strings.ToLower(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+	name = strings.TrimSpace(nameSeparators.ReplaceAllString(name, " "))
+
+	normalized := nameCopyPrefix.ReplaceAllString(name, "")
+	for {
+		trimmed := nameCopySuffix.ReplaceAllString(normalized, "")
+		if trimmed == normalized {
+			break
+		}
+		normalized = trimmed
+	}
+	if normalized == "" {
+		return name
+	}
+	return normalized
+}
+
+// similarNames reports whether nameSimilarity(a, b) is at least threshold,
+// skipping the edit distance when the lengths alone rule it out
+func similarNames(a, b string, threshold float64) bool {
+	if a == b {
+		return true
+	}
+	if nameNumbers.ReplaceAllString(a, "#") == nameNumbers.ReplaceAllString(b, "#") {
+		return false
+	}
+	if tokenSimilarity(strings.Fields(a), strings.Fields(b)) >= threshold {
+		return true
+	}
+
+	// The edit distance is at least the difference in length
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	if float64(min(la, lb))/float64(max(la, lb)) < threshold {
+		return false
+	}
+	return nameSimilarity(a, b) >= threshold
+}
+
+// nameSimilarity returns a score between 0 and 1, the better of the edit
+// distance ratio and the share of common words. Names only differing in their
+// numbers, like IMG_0001 and IMG_0002 or report 2023 and report 2024, are
+// different files and score 0.
+func nameSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if nameNumbers.ReplaceAllString(a, "#") == nameNumbers.ReplaceAllString(b, "#") {
+		return 0
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	editScore := 1 - float64(levenshtein(ra, rb))/float64(longest)
+
+	return max(editScore, tokenSimilarity(strings.Fields(a), strings.Fields(b)))
+}
+
+// levenshtein returns the number of single rune edits needed to turn a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// tokenSimilarity returns the Jaccard index of the two word sets
+func tokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, token := range a {
+		set[token] = true
+	}
+
+	union := len(set)
+	var shared int
+	seen := make(map[string]bool, len(b))
+	for _, token := range b {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		if set[token] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+func renderSimilarNamesToTable(similar []types.SimilarNameResult, groupCount int) {
+	t := table.Table{}
+	var totalFileSize int64
+	t.AppendHeader(table.Row{"Group", "Directory", "FileName", "Normalized", "FileSize", "Similarity"})
+	for _, result := range similar {
+		totalFileSize += result.Entry.Size
+		t.AppendRow(table.Row{
+			pterm.Sprintf("%v", result.Group),
+			formatResultHyperLink(result.Entry.Directory, result.Entry.Directory),
+			formatResultHyperLink(entryPath(result.Entry), result.Entry.FileName),
+			result.NormalizedName,
+			result.Entry.FileSize,
+			pterm.Sprintf("%.0f%%", result.Similarity*100),
+		})
+	}
+	t.AppendFooter(table.Row{
+		pterm.Sprintf("%v Groups", groupCount),
+		"Total",
+		pterm.Sprintf("%v", len(similar)),
+		"",
+		commonFormatters.FormatSize(totalFileSize),
+		"",
+	})
+
+	renderTable(&t)
+}
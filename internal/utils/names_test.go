@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestNormalizeFileName checks case, copy markers, separators and extensions are dropped
+func TestNormalizeFileName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"report (1).pdf", "report"},
+		{"report-copy.pdf", "report"},
+		{"Report_final.pdf", "report"},
+		{"Copy of Quarterly.Report.xlsx", "quarterly report"},
+		{"copy.txt", "copy"},
+		{"IMG_0001.jpg", "img 0001"},
+		{"old-report.pdf", "old report"},
+		{"report-old.pdf", "report"},
+		{"backup_notes copy 2 (1).txt", "backup notes"},
+		{"bold.txt", "bold"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeFileName(tt.input); got != tt.expected {
+			t.Errorf("normalizeFileName(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestFindSimilarNames checks similar names are grouped and unrelated names are not
+func TestFindSimilarNames(t *testing.T) {
+	entries := []types.EntryResult{
+		{Directory: "/docs", FileName: "report (1).pdf"},
+		{Directory: "/docs", FileName: "Report_final.pdf"},
+		{Directory: "/docs", FileName: "reports.docx"},
+		{Directory: "/docs", FileName: "holiday.jpg"},
+	}
+
+	similar := findAndDisplaySimilarNames(entries, types.FileFinder{NameSimilarity: 0.8})
+	if len(similar) != 3 {
+		t.Fatalf("expected 3 similar names, got %+v", similar)
+	}
+	for _, result := range similar {
+		if result.Group != 1 || result.Entry.FileName == "holiday.jpg" {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+}
+
+// TestFindSimilarNamesSequential checks numbered names are not grouped and
+// matches are not chained into one group
+func TestFindSimilarNamesSequential(t *testing.T) {
+	var entries []types.EntryResult
+	for _, name := range []string{"IMG_0001.jpg", "IMG_0002.jpg", "IMG_0003.jpg", "report 2023.pdf", "report 2024.pdf"} {
+		entries = append(entries, types.EntryResult{Directory: "/photos", FileName: name})
+	}
+	if similar := findAndDisplaySimilarNames(entries, types.FileFinder{NameSimilarity: 0.8}); len(similar) != 0 {
+		t.Errorf("expected names only differing in numbers not to be grouped, got %+v", similar)
+	}
+
+	// abcd is close to both, but abcde is not close enough to abc to join its group
+	names := []string{"abc", "abcd", "abcde"}
+	clusters := clusterAroundFirst(len(names), func(i, j int) bool {
+		return nameSimilarity(names[i], names[j]) >= 0.75
+	})
+	if len(clusters) != 1 || len(clusters[0]) != 2 || clusters[0][0] != 0 || clusters[0][1] != 1 {
+		t.Errorf("expected only abc and abcd to be grouped, got %v", clusters)
+	}
+}
+
+// TestSimilarNames checks the pruned comparison agrees with nameSimilarity
+func TestSimilarNames(t *testing.T) {
+	names := []string{"report", "reports", "old report", "quarterly report", "img 0001", "img 0002", "holiday", "a", "abcdefgh"}
+	for _, threshold := range []float64{0.5, 0.8} {
+		for _, a := range names {
+			for _, b := range names {
+				if got, expected := similarNames(a, b, threshold), nameSimilarity(a, b) >= threshold; got != expected {
+					t.Errorf("similarNames(%q, %q, %v) = %v, expected %v", a, b, threshold, got, expected)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+
+	"file-finder/internal/types"
+)
+
+// ToOutputFormat converts a string to an OutputFormat, returning "" when unknown
+func ToOutputFormat(format string) types.OutputFormat {
+	for _, f := range []types.OutputFormat{
+		types.OutputFormats.Table,
+		types.OutputFormats.Tree,
+		types.OutputFormats.HTML,
+		types.OutputFormats.Markdown,
+	} {
+		if strings.EqualFold(format, string(f)) {
+			return f
+		}
+	}
+	return ""
+}
+
+// renderResults sorts the found files, in place so later actions see the
+// same order, and renders them in the requested output format
+func renderResults(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder) {
+	sortResults(results, ff.SortBy, ff.SortReverse)
+
+	if ff.Template != "" {
+		renderResultsWithTemplate(results, ff)
+		return
+	}
+
+	switch ff.OutputFormat {
+	case types.OutputFormats.Tree:
+		renderResultsToTree(results, totalCount, totalFileSize, ff)
+	case types.OutputFormats.HTML:
+		renderResultsToHTML(results, totalCount, totalFileSize, ff)
+	case types.OutputFormats.Markdown:
+		renderResultsToMarkdown(results, totalCount, totalFileSize, ff)
+	default:
+		renderResultsToTable(results, totalCount, totalFileSize, ff)
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// pagerDisabled is set by --no-pager to always print output directly
+var pagerDisabled bool
+
+// DisablePager makes output taller than the screen print directly instead of
+// going through $PAGER or the built-in pager
+func DisablePager() {
+	pagerDisabled = true
+}
+
+// page prints output, through $PAGER or the built-in pager when it does not
+// fit on a screen of height lines
+func page(output string, height int) {
+	lines := strings.Split(output, "\n")
+	if pagerDisabled || height <= 1 || len(lines) < height || !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println(output)
+		return
+	}
+
+	if pager := os.Getenv("PAGER"); pager != "" {
+		if err := runPager(pager, output); err == nil {
+			return
+		}
+		// The built-in pager takes over when $PAGER cannot be run
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fmt.Println(output)
+		return
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	pageLines(os.Stdout, os.Stdin, lines, height-1)
+}
+
+// runPager pipes output into the pager command
+func runPager(pager, output string) error {
+	args, err := splitCommandLine(pager)
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("invalid pager: %s", pager)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(output + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// less shows escape sequences literally unless told otherwise, the same
+	// default git uses
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd.Run()
+}
+
+// pageLines shows pageSize lines at a time, reading keys from input: space or
+// f for the next page, enter or j for the next line, q to stop. The terminal
+// is in raw mode so lines end in \r\n.
+func pageLines(w io.Writer, input io.Reader, lines []string, pageSize int) {
+	shown := 0
+	show := func(count int) {
+		for ; count > 0 && shown < len(lines); count-- {
+			fmt.Fprintf(w, "%s\r\n", lines[shown])
+			shown++
+		}
+	}
+
+	show(pageSize)
+	keys := bufio.NewReader(input)
+	for shown < len(lines) {
+		fmt.Fprintf(w, "\x1b[7m-- More -- (%d%%) space: page, enter: line, q: quit\x1b[0m", shown*100/len(lines))
+		key, err := keys.ReadByte()
+		// Clear the prompt before showing more
+		fmt.Fprint(w, "\r\x1b[K")
+		if err != nil {
+			return
+		}
+
+		switch key {
+		case ' ', 'f':
+			show(pageSize)
+		case '\r', '\n', 'j':
+			show(1)
+		case 'q', 'Q', 3: // Ctrl+C arrives as a byte in raw mode
+			return
+		}
+	}
+}
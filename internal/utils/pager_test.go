@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestPageLines checks the built-in pager shows a page, then lines and pages as keys are read
+func TestPageLines(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+
+	tests := []struct {
+		keys     string
+		expected int
+	}{
+		{"", 3},
+		{"q", 3},
+		{"\r", 4},
+		{" ", 6},
+		{"\rj q", 8},
+		{"    ", 10},
+	}
+
+	for _, tt := range tests {
+		var sb strings.Builder
+		pageLines(&sb, strings.NewReader(tt.keys), lines, 3)
+
+		shown := strings.Count(sb.String(), "\r\n")
+		if shown != tt.expected {
+			t.Errorf("keys %q showed %d lines, expected %d", tt.keys, shown, tt.expected)
+		}
+		if tt.expected == len(lines) && !strings.HasSuffix(sb.String(), "line 10\r\n") {
+			t.Errorf("keys %q left a prompt after the last line", tt.keys)
+		}
+	}
+}
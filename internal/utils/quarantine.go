@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/pterm/pterm"
+)
+
+const quarantineManifestName = "quarantine-manifest.json"
+
+// QuarantineFiles moves the found files into the quarantine directory, keeping
+// their path relative to the root directory, and records them in the manifest
+func QuarantineFiles(results interface{}, ff types.FileFinder) {
+	entries, err := toEntryResults(results)
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	result, _ := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Are you sure you want to move these files to %s?", ff.QuarantineDirectory))
+	if !result {
+		pterm.Info.Println("Quarantine cancelled.")
+		return
+	}
+
+	if err := quarantineEntryResults(entries, ff.RootDirectory, ff.QuarantineDirectory); err != nil {
+		pterm.Error.Printf("error quarantining files: %v\n", err)
+	}
+}
+
+func quarantineEntryResults(entries []types.EntryResult, rootDirectory, quarantineDirectory string) error {
+	spinner := startSpinner("Moving files to quarantine...")
+	defer spinner.Stop()
+
+	absQuarantine, err := filepath.Abs(quarantineDirectory)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := loadQuarantineManifest(absQuarantine)
+	if err != nil {
+		return err
+	}
+
+	var movedCount int
+	var directoriesToRemove, roots []string
+	for _, entry := range entries {
+		src, err := filepath.Abs(filepath.Join(entry.Directory, entry.FileName))
+		if err != nil {
+			pterm.Error.Printf("Error resolving %s: %v\n", entry.FileName, err)
+			continue
+		}
+
+		info, err := os.Stat(src)
+		if err != nil {
+			pterm.Error.Printf("Error quarantining %s: %v\n", src, err)
+			continue
+		}
+
+		absRoot, err := filepath.Abs(entryRoot(entry, rootDirectory))
+		if err != nil {
+			pterm.Error.Printf("Error resolving %s: %v\n", entry.FileName, err)
+			continue
+		}
+
+		dst := nextAvailablePath(filepath.Join(absQuarantine, relativeToRoot(absRoot, src)))
+
+		hash, err := moveFile(src, dst)
+		if err != nil {
+			pterm.Error.Printf("Error quarantining %s: %v\n", src, err)
+			continue
+		}
+
+		quarantinePath, _ := filepath.Rel(absQuarantine, dst)
+		entry := types.QuarantineEntry{
+			OriginalPath:   src,
+			QuarantinePath: quarantinePath,
+			Size:           info.Size(),
+			Hash:           hash,
+			QuarantinedAt:  time.Now(),
+		}
+		if hash != "" {
+			entry.HashAlgorithm = types.HashAlgorithms.SHA256
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+		directoriesToRemove = append(directoriesToRemove, filepath.Dir(src))
+		roots = append(roots, absRoot)
+		movedCount++
+	}
+
+	if err := saveQuarantineManifest(absQuarantine, manifest); err != nil {
+		return fmt.Errorf("error writing quarantine manifest: %w", err)
+	}
+
+	for i, dir := range directoriesToRemove {
+		removeEmptyParents(dir, roots[i])
+	}
+
+	spinner.Success(fmt.Sprintf("Moved %d files to %s.", movedCount, absQuarantine))
+	return nil
+}
+
+// PurgeQuarantine permanently deletes quarantined files older than the given age
+func PurgeQuarantine(quarantineDirectory string, olderThan time.Duration) error {
+	absQuarantine, err := filepath.Abs(quarantineDirectory)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := loadQuarantineManifest(absQuarantine)
+	if err != nil {
+		return err
+	}
+
+	spinner := startSpinner("Purging quarantined files...")
+	defer spinner.Stop()
+
+	cutoff := time.Now().Add(-olderThan)
+	var kept []types.QuarantineEntry
+	var purgedCount int
+	for _, entry := range manifest.Entries {
+		if entry.QuarantinedAt.After(cutoff) {
+			kept = append(kept, entry)
+			continue
+		}
+
+		path := filepath.Join(absQuarantine, entry.QuarantinePath)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			pterm.Error.Printf("Error deleting %s: %v\n", path, err)
+			kept = append(kept, entry)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(path), absQuarantine)
+		purgedCount++
+	}
+
+	manifest.Entries = kept
+	if err := saveQuarantineManifest(absQuarantine, manifest); err != nil {
+		return fmt.Errorf("error writing quarantine manifest: %w", err)
+	}
+
+	spinner.Success(fmt.Sprintf("Purged %d files, %d remain in quarantine.", purgedCount, len(kept)))
+	return nil
+}
+
+// RestoreQuarantine moves quarantined files back to their original location
+func RestoreQuarantine(quarantineDirectory string) error {
+	absQuarantine, err := filepath.Abs(quarantineDirectory)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := loadQuarantineManifest(absQuarantine)
+	if err != nil {
+		return err
+	}
+
+	spinner := startSpinner("Restoring quarantined files...")
+	defer spinner.Stop()
+
+	var kept []types.QuarantineEntry
+	var restoredCount int
+	for _, entry := range manifest.Entries {
+		path := filepath.Join(absQuarantine, entry.QuarantinePath)
+
+		if _, err := os.Lstat(entry.OriginalPath); err == nil {
+			pterm.Warning.Printf("Skipping %s: original path already exists\n", entry.OriginalPath)
+			kept = append(kept, entry)
+			continue
+		}
+
+		if _, err := moveFile(path, entry.OriginalPath); err != nil {
+			pterm.Error.Printf("Error restoring %s: %v\n", entry.OriginalPath, err)
+			kept = append(kept, entry)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(path), absQuarantine)
+		restoredCount++
+	}
+
+	manifest.Entries = kept
+	if err := saveQuarantineManifest(absQuarantine, manifest); err != nil {
+		return fmt.Errorf("error writing quarantine manifest: %w", err)
+	}
+
+	spinner.Success(fmt.Sprintf("Restored %d files, %d remain in quarantine.", restoredCount, len(kept)))
+	return nil
+}
+
+func loadQuarantineManifest(quarantineDirectory string) (types.QuarantineManifest, error) {
+	var manifest types.QuarantineManifest
+
+	data, err := os.ReadFile(filepath.Join(quarantineDirectory, quarantineManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid quarantine manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func saveQuarantineManifest(quarantineDirectory string, manifest types.QuarantineManifest) error {
+	if err := os.MkdirAll(quarantineDirectory, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(quarantineDirectory, quarantineManifestName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isQuarantineDirectory reports whether path is the configured quarantine
+// directory, so scans never descend into files that are already quarantined
+func isQuarantineDirectory(path string, ff types.FileFinder) bool {
+	if ff.QuarantineDirectory == "" {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absQuarantine, err := filepath.Abs(ff.QuarantineDirectory)
+	if err != nil {
+		return false
+	}
+	return absPath == absQuarantine
+}
+
+// removeEmptyParents removes dir and its parents while they are empty, never
+// going above stop
+func removeEmptyParents(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		empty, err := isDirEmpty(dir)
+		if err != nil || !empty {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
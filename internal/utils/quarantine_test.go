@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+)
+
+// TestQuarantineAndRestore checks that quarantined files keep their relative path and can be restored
+func TestQuarantineAndRestore(t *testing.T) {
+	root := t.TempDir()
+	quarantine := t.TempDir()
+
+	dir := filepath.Join(root, "nested")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	original := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(original, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []types.EntryResult{{Directory: dir, FileName: "file.txt"}}
+	if err := quarantineEntryResults(entries, root, quarantine); err != nil {
+		t.Fatalf("quarantineEntryResults returned error: %v", err)
+	}
+
+	if _, err := os.Stat(original); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be moved, got err %v", original, err)
+	}
+	if _, err := os.Stat(filepath.Join(quarantine, "nested", "file.txt")); err != nil {
+		t.Errorf("expected file in quarantine, got err %v", err)
+	}
+
+	manifest, err := loadQuarantineManifest(quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Entries) != 1 || manifest.Entries[0].OriginalPath != original {
+		t.Fatalf("unexpected manifest entries %+v", manifest.Entries)
+	}
+
+	if err := RestoreQuarantine(quarantine); err != nil {
+		t.Fatalf("RestoreQuarantine returned error: %v", err)
+	}
+	if _, err := os.Stat(original); err != nil {
+		t.Errorf("expected %s to be restored, got err %v", original, err)
+	}
+}
+
+// TestParseAge checks the day and week suffixes along with regular durations
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"", 0, true},
+		{"xd", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseAge(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"file-finder/internal/types"
+
+	"github.com/pterm/pterm"
+)
+
+const selectionMaxHeight = 15
+
+// SelectFiles lets the user pick which of the found files to act on. Besides
+// one option per file there is one option per directory which selects every
+// file directly inside it. A selected directory always includes all of its
+// files, deselecting one of them has no effect, so to leave files out select
+// them individually instead. Duplicates kept by the keep policy are listed
+// too, marked as kept and left out of the directory options, so the policy
+// can be overridden by selecting them individually. Typing filters the list,
+// the right arrow selects all options and the left arrow clears the selection.
+func SelectFiles(results interface{}) interface{} {
+	entries, kept, err := selectableEntries(results)
+	if err != nil {
+		pterm.Error.Println(err)
+		return results
+	}
+
+	if len(entries) == 0 {
+		return entries
+	}
+
+	directoryOptions, fileOptions, optionDirectories := selectionOptions(entries, kept)
+	options := append(append([]string{}, directoryOptions...), fileOptions...)
+
+	selected, err := pterm.DefaultInteractiveMultiselect.
+		WithOptions(options).
+		WithFilter(true).
+		WithMaxHeight(selectionMaxHeight).
+		Show("Select files (enter: toggle, tab: confirm, →: all, ←: none)")
+	if err != nil {
+		pterm.Error.Printf("error selecting files: %v\n", err)
+		return []types.EntryResult{}
+	}
+
+	selection := selectEntries(entries, kept, fileOptions, optionDirectories, selected)
+	pterm.Info.Printf("%d of %d files selected\n", len(selection), len(entries))
+	return selection
+}
+
+// selectableEntries returns the entries to choose from and the paths of the
+// duplicates kept by the keep policy among them
+func selectableEntries(results interface{}) ([]types.EntryResult, map[string]bool, error) {
+	duplicates, ok := results.([]types.DuplicateResult)
+	if !ok {
+		entries, err := toEntryResults(results)
+		return entries, nil, err
+	}
+
+	entries := make([]types.EntryResult, 0, len(duplicates))
+	kept := make(map[string]bool)
+	for _, duplicate := range duplicates {
+		entries = append(entries, duplicate.Entry)
+		if duplicate.Status == types.DuplicateStatuses.Keep {
+			kept[entryPath(duplicate.Entry)] = true
+		}
+	}
+	return entries, kept, nil
+}
+
+// selectionOptions returns one option per directory holding files that are
+// not kept, in order of first appearance, one option per entry and the
+// directory of each directory option
+func selectionOptions(entries []types.EntryResult, kept map[string]bool) ([]string, []string, map[string]string) {
+	counts := make(map[string]int)
+	var directories []string
+	for _, entry := range entries {
+		if kept[entryPath(entry)] {
+			continue
+		}
+		if counts[entry.Directory] == 0 {
+			directories = append(directories, entry.Directory)
+		}
+		counts[entry.Directory]++
+	}
+
+	directoryOptions := make([]string, 0, len(directories))
+	optionDirectories := make(map[string]string, len(directories))
+	for _, dir := range directories {
+		option := fmt.Sprintf("[dir] %s (%d files)", dir, counts[dir])
+		directoryOptions = append(directoryOptions, option)
+		optionDirectories[option] = dir
+	}
+
+	fileOptions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		option := fmt.Sprintf("%10s  %s", entry.FileSize, filepath.Join(entry.Directory, entry.FileName))
+		if kept[entryPath(entry)] {
+			option += " [kept]"
+		}
+		fileOptions = append(fileOptions, option)
+	}
+
+	return directoryOptions, fileOptions, optionDirectories
+}
+
+// selectEntries returns the entries whose own option or directory option was
+// selected, once each and in their original order. Kept duplicates are only
+// returned when their own option was selected.
+func selectEntries(entries []types.EntryResult, kept map[string]bool, fileOptions []string, optionDirectories map[string]string, selected []string) []types.EntryResult {
+	selectedOptions := make(map[string]bool, len(selected))
+	selectedDirectories := make(map[string]bool)
+	for _, option := range selected {
+		selectedOptions[option] = true
+		if dir, ok := optionDirectories[option]; ok {
+			selectedDirectories[dir] = true
+		}
+	}
+
+	selection := []types.EntryResult{}
+	for i, entry := range entries {
+		inSelectedDirectory := selectedDirectories[entry.Directory] && !kept[entryPath(entry)]
+		if inSelectedDirectory || selectedOptions[fileOptions[i]] {
+			selection = append(selection, entry)
+		}
+	}
+	return selection
+}
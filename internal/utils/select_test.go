@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestSelectionOptions checks there is an option per directory, in order of first appearance, and per file
+func TestSelectionOptions(t *testing.T) {
+	entries := []types.EntryResult{
+		{Directory: "/b", FileName: "1.txt", FileSize: "1 B"},
+		{Directory: "/a", FileName: "2.txt", FileSize: "2 B"},
+		{Directory: "/b", FileName: "3.txt", FileSize: "3 B"},
+	}
+
+	directoryOptions, fileOptions, _ := selectionOptions(entries, nil)
+	expected := []string{"[dir] /b (2 files)", "[dir] /a (1 files)"}
+	if len(directoryOptions) != len(expected) || directoryOptions[0] != expected[0] || directoryOptions[1] != expected[1] {
+		t.Errorf("expected directory options %q, got %q", expected, directoryOptions)
+	}
+	if len(fileOptions) != len(entries) {
+		t.Errorf("expected %d file options, got %q", len(entries), fileOptions)
+	}
+}
+
+// TestSelectEntries checks directory options expand to their files, files are
+// returned once and the original order is kept
+func TestSelectEntries(t *testing.T) {
+	entries := []types.EntryResult{
+		{Directory: "/b", FileName: "1.txt", FileSize: "1 B"},
+		{Directory: "/a", FileName: "2.txt", FileSize: "2 B"},
+		{Directory: "/b", FileName: "3.txt", FileSize: "3 B"},
+		{Directory: "/c", FileName: "4.txt", FileSize: "4 B"},
+	}
+	directoryOptions, fileOptions, optionDirectories := selectionOptions(entries, nil)
+
+	tests := []struct {
+		name     string
+		selected []string
+		expected []string
+	}{
+		{"nothing", nil, nil},
+		{"directory", []string{directoryOptions[0]}, []string{"1.txt", "3.txt"}},
+		{"directory and own file", []string{fileOptions[2], directoryOptions[0]}, []string{"1.txt", "3.txt"}},
+		{"files out of order", []string{fileOptions[3], fileOptions[1]}, []string{"2.txt", "4.txt"}},
+		{"directory and other file", []string{fileOptions[3], directoryOptions[1]}, []string{"2.txt", "4.txt"}},
+	}
+
+	for _, tt := range tests {
+		selection := selectEntries(entries, nil, fileOptions, optionDirectories, tt.selected)
+		if len(selection) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.expected, selection)
+			continue
+		}
+		for i, entry := range selection {
+			if entry.FileName != tt.expected[i] {
+				t.Errorf("%s: selection %d = %s, expected %s", tt.name, i, entry.FileName, tt.expected[i])
+			}
+		}
+	}
+}
+
+// TestSelectKeptDuplicates checks kept duplicates are offered but only
+// selected through their own option
+func TestSelectKeptDuplicates(t *testing.T) {
+	duplicates := []types.DuplicateResult{
+		{Entry: types.EntryResult{Directory: "/a", FileName: "1.txt", FileSize: "1 B"}, Status: types.DuplicateStatuses.Keep},
+		{Entry: types.EntryResult{Directory: "/a", FileName: "2.txt", FileSize: "1 B"}, Status: types.DuplicateStatuses.Remove},
+	}
+	entries, kept, err := selectableEntries(duplicates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directoryOptions, fileOptions, optionDirectories := selectionOptions(entries, kept)
+	if len(fileOptions) != 2 || len(directoryOptions) != 1 || directoryOptions[0] != "[dir] /a (1 files)" {
+		t.Fatalf("expected both files and a directory option for the removed one, got %q and %q", directoryOptions, fileOptions)
+	}
+
+	tests := []struct {
+		name     string
+		selected []string
+		expected []string
+	}{
+		{"directory", []string{directoryOptions[0]}, []string{"2.txt"}},
+		{"kept file", []string{fileOptions[0]}, []string{"1.txt"}},
+	}
+
+	for _, tt := range tests {
+		selection := selectEntries(entries, kept, fileOptions, optionDirectories, tt.selected)
+		if len(selection) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.expected, selection)
+			continue
+		}
+		for i, entry := range selection {
+			if entry.FileName != tt.expected[i] {
+				t.Errorf("%s: selection %d = %s, expected %s", tt.name, i, entry.FileName, tt.expected[i])
+			}
+		}
+	}
+}
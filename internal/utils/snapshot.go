@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// SaveSnapshot scans the root directories and writes the matching files with
+// their sizes, modification times and hashes to snapshotFile
+func SaveSnapshot(ff types.FileFinder, snapshotFile string) error {
+	ff.DisplayDetailedResults = true
+	results, _, _, err := getFilesFromRoots(ff)
+	if err != nil {
+		return err
+	}
+
+	absSnapshot, err := filepath.Abs(snapshotFile)
+	if err != nil {
+		return err
+	}
+
+	// A snapshot saved inside a root would show up as added in the next one
+	var entries []types.EntryResult
+	for _, entry := range results.([]types.EntryResult) {
+		path, err := filepath.Abs(entryPath(entry))
+		if err != nil {
+			return err
+		}
+		if path != absSnapshot {
+			entries = append(entries, entry)
+		}
+	}
+
+	snapshot := types.Snapshot{
+		CreatedAt: time.Now(),
+		Files:     make([]types.FileInfo, 0, len(entries)),
+	}
+	for _, root := range ff.RootDirectories {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		snapshot.Roots = append(snapshot.Roots, absRoot)
+	}
+
+	hasher := getHasher(ff.HashAlgorithm)
+	hashes := hashEntries(entries, hasher)
+	for i, entry := range entries {
+		path, err := filepath.Abs(entryPath(entry))
+		if err != nil {
+			return err
+		}
+		snapshot.Files = append(snapshot.Files, types.FileInfo{
+			Path:          path,
+			Size:          entry.Size,
+			Hash:          hashes[i],
+			HashAlgorithm: hasher.Algorithm(),
+			ModTime:       entry.ModTime,
+		})
+	}
+	sort.Slice(snapshot.Files, func(i, j int) bool {
+		return snapshot.Files[i].Path < snapshot.Files[j].Path
+	})
+
+	if err := writeSnapshot(snapshotFile, snapshot); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Saved a snapshot of %d files to %s\n", len(snapshot.Files), snapshotFile)
+	return nil
+}
+
+// DiffSnapshots reports what changed between the snapshots in fromFile and
+// toFile, as a table or as JSON
+func DiffSnapshots(fromFile, toFile string, jsonOutput bool) error {
+	from, err := readSnapshot(fromFile)
+	if err != nil {
+		return err
+	}
+	to, err := readSnapshot(toFile)
+	if err != nil {
+		return err
+	}
+
+	changes := diffSnapshots(from, to)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	if len(changes) == 0 {
+		pterm.Info.Printf("No changes between %s (%s) and %s (%s)\n", fromFile, from.CreatedAt.Format(time.DateTime), toFile, to.CreatedAt.Format(time.DateTime))
+		return nil
+	}
+
+	renderSnapshotChangesToTable(changes)
+	return nil
+}
+
+func writeSnapshot(snapshotFile string, snapshot types.Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(snapshotFile), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := snapshotFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, snapshotFile)
+}
+
+func readSnapshot(snapshotFile string) (types.Snapshot, error) {
+	var snapshot types.Snapshot
+
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("invalid snapshot %s: %w", snapshotFile, err)
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots compares two snapshots by path. Files removed from one path and
+// added at another with the same hash are reported as moved, files that only
+// got bigger as grown.
+func diffSnapshots(from, to types.Snapshot) []types.SnapshotChange {
+	fromFiles := make(map[string]types.FileInfo, len(from.Files))
+	for _, file := range from.Files {
+		fromFiles[file.Path] = file
+	}
+	toFiles := make(map[string]types.FileInfo, len(to.Files))
+	for _, file := range to.Files {
+		toFiles[file.Path] = file
+	}
+
+	var changes []types.SnapshotChange
+	var removed, added []types.FileInfo
+	for _, file := range from.Files {
+		current, ok := toFiles[file.Path]
+		if !ok {
+			removed = append(removed, file)
+			continue
+		}
+
+		change := types.SnapshotChange{Path: file.Path, Size: current.Size, PreviousSize: file.Size}
+		switch {
+		case !fileChanged(file, current):
+			continue
+		case current.Size > file.Size:
+			change.Change = types.SnapshotChangeTypes.Grown
+		default:
+			change.Change = types.SnapshotChangeTypes.Modified
+		}
+		changes = append(changes, change)
+	}
+	for _, file := range to.Files {
+		if _, ok := fromFiles[file.Path]; !ok {
+			added = append(added, file)
+		}
+	}
+
+	// Pair up removed and added files with the same content as moves
+	removedByHash := make(map[string][]types.FileInfo)
+	for _, file := range removed {
+		if file.Hash != "" {
+			removedByHash[snapshotHashKey(file)] = append(removedByHash[snapshotHashKey(file)], file)
+		}
+	}
+	moved := make(map[string]bool)
+	for _, file := range added {
+		candidates := removedByHash[snapshotHashKey(file)]
+		if file.Hash == "" || len(candidates) == 0 {
+			changes = append(changes, types.SnapshotChange{Change: types.SnapshotChangeTypes.Added, Path: file.Path, Size: file.Size})
+			continue
+		}
+
+		previous := candidates[0]
+		removedByHash[snapshotHashKey(file)] = candidates[1:]
+		moved[previous.Path] = true
+		changes = append(changes, types.SnapshotChange{
+			Change:       types.SnapshotChangeTypes.Moved,
+			Path:         file.Path,
+			PreviousPath: previous.Path,
+			Size:         file.Size,
+			PreviousSize: previous.Size,
+		})
+	}
+	for _, file := range removed {
+		if !moved[file.Path] {
+			changes = append(changes, types.SnapshotChange{Change: types.SnapshotChangeTypes.Removed, Path: file.Path, PreviousSize: file.Size})
+		}
+	}
+
+	order := map[types.SnapshotChangeType]int{
+		types.SnapshotChangeTypes.Added:    0,
+		types.SnapshotChangeTypes.Removed:  1,
+		types.SnapshotChangeTypes.Modified: 2,
+		types.SnapshotChangeTypes.Grown:    3,
+		types.SnapshotChangeTypes.Moved:    4,
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Change != changes[j].Change {
+			return order[changes[i].Change] < order[changes[j].Change]
+		}
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// fileChanged compares hashes when both snapshots have one made with the same
+// algorithm, sizes and modification times otherwise
+func fileChanged(from, to types.FileInfo) bool {
+	if from.Size != to.Size {
+		return true
+	}
+	if from.Hash != "" && to.Hash != "" && from.HashAlgorithm == to.HashAlgorithm {
+		return from.Hash != to.Hash
+	}
+	return !from.ModTime.Equal(to.ModTime)
+}
+
+// snapshotHashKey identifies a file's content, hashes only match when made
+// with the same algorithm
+func snapshotHashKey(file types.FileInfo) string {
+	return string(file.HashAlgorithm) + ":" + file.Hash
+}
+
+func renderSnapshotChangesToTable(changes []types.SnapshotChange) {
+	t := table.Table{}
+	counts := make(map[types.SnapshotChangeType]int)
+	t.AppendHeader(table.Row{"Change", "Path", "Size"})
+	for _, change := range changes {
+		counts[change.Change]++
+
+		path := formatResultHyperLink(change.Path, change.Path)
+		size := commonFormatters.FormatSize(change.Size)
+		switch change.Change {
+		case types.SnapshotChangeTypes.Removed:
+			path = change.Path
+			size = commonFormatters.FormatSize(change.PreviousSize)
+		case types.SnapshotChangeTypes.Moved:
+			path = pterm.Sprintf("%s\n-> %s", change.PreviousPath, path)
+		case types.SnapshotChangeTypes.Modified, types.SnapshotChangeTypes.Grown:
+			size = pterm.Sprintf("%s -> %s", commonFormatters.FormatSize(change.PreviousSize), size)
+		}
+		t.AppendRow(table.Row{string(change.Change), path, size})
+	}
+	t.AppendFooter(table.Row{
+		"Total Changes",
+		pterm.Sprintf("%v", len(changes)),
+		pterm.Sprintf("%d added, %d removed, %d modified, %d grown, %d moved",
+			counts[types.SnapshotChangeTypes.Added], counts[types.SnapshotChangeTypes.Removed], counts[types.SnapshotChangeTypes.Modified],
+			counts[types.SnapshotChangeTypes.Grown], counts[types.SnapshotChangeTypes.Moved]),
+	})
+
+	renderTable(&t)
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestDiffSnapshots checks every kind of change is found and unchanged files are not reported
+func TestDiffSnapshots(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := types.Snapshot{Files: []types.FileInfo{
+		{Path: "/share/same.txt", Size: 10, Hash: "same", ModTime: modTime},
+		{Path: "/share/removed.txt", Size: 10, Hash: "removed", ModTime: modTime},
+		{Path: "/share/edited.txt", Size: 10, Hash: "before", ModTime: modTime},
+		{Path: "/share/app.log", Size: 10, Hash: "log", ModTime: modTime},
+		{Path: "/share/old/photo.jpg", Size: 20, Hash: "photo", ModTime: modTime},
+		{Path: "/share/touched.txt", Size: 10, ModTime: modTime},
+	}}
+	to := types.Snapshot{Files: []types.FileInfo{
+		{Path: "/share/same.txt", Size: 10, Hash: "same", ModTime: modTime},
+		{Path: "/share/added.txt", Size: 5, Hash: "added", ModTime: modTime},
+		{Path: "/share/edited.txt", Size: 10, Hash: "after", ModTime: modTime},
+		{Path: "/share/app.log", Size: 30, Hash: "log2", ModTime: modTime},
+		{Path: "/share/new/photo.jpg", Size: 20, Hash: "photo", ModTime: modTime},
+		{Path: "/share/touched.txt", Size: 10, ModTime: modTime.Add(time.Hour)},
+	}}
+
+	expected := []types.SnapshotChange{
+		{Change: types.SnapshotChangeTypes.Added, Path: "/share/added.txt", Size: 5},
+		{Change: types.SnapshotChangeTypes.Removed, Path: "/share/removed.txt", PreviousSize: 10},
+		{Change: types.SnapshotChangeTypes.Modified, Path: "/share/edited.txt", Size: 10, PreviousSize: 10},
+		{Change: types.SnapshotChangeTypes.Modified, Path: "/share/touched.txt", Size: 10, PreviousSize: 10},
+		{Change: types.SnapshotChangeTypes.Grown, Path: "/share/app.log", Size: 30, PreviousSize: 10},
+		{Change: types.SnapshotChangeTypes.Moved, Path: "/share/new/photo.jpg", PreviousPath: "/share/old/photo.jpg", Size: 20, PreviousSize: 20},
+	}
+
+	changes := diffSnapshots(from, to)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("change %d = %+v, expected %+v", i, changes[i], expected[i])
+		}
+	}
+}
+
+// TestSaveSnapshotSkipsItself checks a snapshot saved inside a root is left out of the next one
+func TestSaveSnapshotSkipsItself(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "file.txt"), []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotFile := filepath.Join(root, "snapshot.json")
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, RootDirectories: []string{root}, RootDirectory: root}
+	for i := 0; i < 2; i++ {
+		if err := SaveSnapshot(ff, snapshotFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := readSnapshot(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Files) != 1 || filepath.Base(snapshot.Files[0].Path) != "file.txt" {
+		t.Errorf("expected only file.txt in the snapshot, got %+v", snapshot.Files)
+	}
+}
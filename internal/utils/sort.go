@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"file-finder/internal/types"
+)
+
+// entryGroup is a set of detailed results sharing the same group key
+type entryGroup struct {
+	name    string
+	entries []types.EntryResult
+	size    int64
+}
+
+// ToSortField converts a string to a SortField, returning "" when unknown
+func ToSortField(field string) types.SortField {
+	for _, f := range []types.SortField{
+		types.SortFields.Path,
+		types.SortFields.Name,
+		types.SortFields.Size,
+		types.SortFields.Count,
+		types.SortFields.ModTime,
+		types.SortFields.Extension,
+	} {
+		if strings.EqualFold(field, string(f)) {
+			return f
+		}
+	}
+	return ""
+}
+
+// ToGroupField converts a string to a GroupField, returning "" when unknown
+func ToGroupField(field string) types.GroupField {
+	for _, f := range []types.GroupField{types.GroupFields.Directory, types.GroupFields.Extension, types.GroupFields.Type} {
+		if strings.EqualFold(field, string(f)) {
+			return f
+		}
+	}
+	return ""
+}
+
+// sortResults orders the results in place by sortBy, falling back to the path
+// so the order is the same on every run. Directory summaries can only be
+// sorted by path, name and count, run rejects the other fields for them.
+func sortResults(results interface{}, sortBy types.SortField, reverse bool) {
+	switch results := results.(type) {
+	case []types.EntryResult:
+		sortEntries(results, sortBy, reverse)
+	case []types.DirectoryResult:
+		sort.SliceStable(results, func(i, j int) bool {
+			a, b := results[i], results[j]
+			c := 0
+			switch sortBy {
+			case types.SortFields.Name:
+				c = strings.Compare(filepath.Base(a.Directory), filepath.Base(b.Directory))
+			case types.SortFields.Count:
+				c = compareInts(int64(a.Count), int64(b.Count))
+			}
+			if c == 0 {
+				c = strings.Compare(a.Directory, b.Directory)
+			}
+			if reverse {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+}
+
+// sortEntries orders the entries in place by sortBy, count being the number
+// of matching files in the entry's directory
+func sortEntries(entries []types.EntryResult, sortBy types.SortField, reverse bool) {
+	counts := make(map[string]int)
+	if sortBy == types.SortFields.Count {
+		for _, entry := range entries {
+			counts[entry.Directory]++
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		c := 0
+		switch sortBy {
+		case types.SortFields.Name:
+			c = strings.Compare(a.FileName, b.FileName)
+		case types.SortFields.Size:
+			c = compareInts(a.Size, b.Size)
+		case types.SortFields.Count:
+			c = compareInts(int64(counts[a.Directory]), int64(counts[b.Directory]))
+		case types.SortFields.ModTime:
+			c = a.ModTime.Compare(b.ModTime)
+		case types.SortFields.Extension:
+			c = strings.Compare(extensionName(a.FileName), extensionName(b.FileName))
+		}
+		if c == 0 {
+			c = strings.Compare(entryPath(a), entryPath(b))
+		}
+		if c == 0 {
+			c = strings.Compare(a.Root, b.Root)
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// groupEntries splits the entries by groupBy, keeping their order within each
+// group, with the groups ordered by name
+func groupEntries(entries []types.EntryResult, groupBy types.GroupField) []entryGroup {
+	byName := make(map[string]*entryGroup)
+	var names []string
+	for _, entry := range entries {
+		name := groupName(entry, groupBy)
+		group, ok := byName[name]
+		if !ok {
+			group = &entryGroup{name: name}
+			byName[name] = group
+			names = append(names, name)
+		}
+		group.entries = append(group.entries, entry)
+		group.size += entry.Size
+	}
+	sort.Strings(names)
+
+	groups := make([]entryGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, *byName[name])
+	}
+	return groups
+}
+
+func groupName(entry types.EntryResult, groupBy types.GroupField) string {
+	switch groupBy {
+	case types.GroupFields.Extension:
+		return extensionName(entry.FileName)
+	case types.GroupFields.Type:
+		return fileTypeName(entry.FileName)
+	}
+	return entry.Directory
+}
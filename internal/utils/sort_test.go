@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+)
+
+// TestSortResults checks entries and directory summaries are ordered by each field, ties by path
+func TestSortResults(t *testing.T) {
+	now := time.Now()
+	entries := []types.EntryResult{
+		{Directory: "/b", FileName: "z.txt", Size: 10, ModTime: now.Add(-time.Hour)},
+		{Directory: "/a", FileName: "y.jpg", Size: 30, ModTime: now.Add(-3 * time.Hour)},
+		{Directory: "/b", FileName: "x.go", Size: 20, ModTime: now.Add(-2 * time.Hour)},
+		{Directory: "/a", FileName: "w.txt", Size: 10, ModTime: now},
+	}
+
+	tests := []struct {
+		sortBy   types.SortField
+		reverse  bool
+		expected []string
+	}{
+		{types.SortFields.Path, false, []string{"w.txt", "y.jpg", "x.go", "z.txt"}},
+		{types.SortFields.Path, true, []string{"z.txt", "x.go", "y.jpg", "w.txt"}},
+		{types.SortFields.Name, false, []string{"w.txt", "x.go", "y.jpg", "z.txt"}},
+		{types.SortFields.Size, false, []string{"w.txt", "z.txt", "x.go", "y.jpg"}},
+		{types.SortFields.Size, true, []string{"y.jpg", "x.go", "z.txt", "w.txt"}},
+		{types.SortFields.ModTime, false, []string{"y.jpg", "x.go", "z.txt", "w.txt"}},
+		{types.SortFields.Extension, false, []string{"x.go", "y.jpg", "w.txt", "z.txt"}},
+	}
+
+	for _, tt := range tests {
+		sorted := append([]types.EntryResult(nil), entries...)
+		sortResults(sorted, tt.sortBy, tt.reverse)
+
+		var names []string
+		for _, entry := range sorted {
+			names = append(names, entry.FileName)
+		}
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("sortResults(%s, reverse %v) = %v, expected %v", tt.sortBy, tt.reverse, names, tt.expected)
+		}
+	}
+
+	directories := []types.DirectoryResult{{Directory: "/c", Count: 1}, {Directory: "/a", Count: 3}, {Directory: "/b", Count: 3}}
+	sortResults(directories, types.SortFields.Count, true)
+	expected := []types.DirectoryResult{{Directory: "/b", Count: 3}, {Directory: "/a", Count: 3}, {Directory: "/c", Count: 1}}
+	if !reflect.DeepEqual(directories, expected) {
+		t.Errorf("expected directories by count reversed %v, got %v", expected, directories)
+	}
+}
+
+// TestGroupEntries checks entries are grouped by name with their order and totals kept
+func TestGroupEntries(t *testing.T) {
+	entries := []types.EntryResult{
+		{Directory: "/b", FileName: "b.JPG", Size: 10},
+		{Directory: "/a", FileName: "a.txt", Size: 5},
+		{Directory: "/a", FileName: "c.jpg", Size: 20},
+	}
+
+	groups := groupEntries(entries, types.GroupFields.Extension)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if groups[0].name != ".jpg" || groups[0].size != 30 || groups[0].entries[0].FileName != "b.JPG" || groups[0].entries[1].FileName != "c.jpg" {
+		t.Errorf("unexpected .jpg group %+v", groups[0])
+	}
+	if groups[1].name != ".txt" || groups[1].size != 5 || len(groups[1].entries) != 1 {
+		t.Errorf("unexpected .txt group %+v", groups[1])
+	}
+
+	if groups := groupEntries(entries, types.GroupFields.Directory); groups[0].name != "/a" || len(groups[0].entries) != 2 {
+		t.Errorf("unexpected directory groups %+v", groups)
+	}
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	commonTypes "github.com/ondrovic/common/types"
+	commonUtils "github.com/ondrovic/common/utils"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// ageBuckets are the modification age buckets of the stats command, files
+// older than the last one are counted as older
+var ageBuckets = []struct {
+	name   string
+	maxAge time.Duration
+}{
+	{"within 1d", 24 * time.Hour},
+	{"within 7d", 7 * 24 * time.Hour},
+	{"within 30d", 30 * 24 * time.Hour},
+	{"within 1y", 365 * 24 * time.Hour},
+}
+
+// statsFileTypes are the categories files are sorted into, in order
+var statsFileTypes = []commonTypes.FileType{
+	commonTypes.FileTypes.Video,
+	commonTypes.FileTypes.Image,
+	commonTypes.FileTypes.Archive,
+	commonTypes.FileTypes.Documents,
+}
+
+// DisplayStats breaks the matching files down by extension, file type, size
+// and age, as tables or as JSON
+func DisplayStats(ff types.FileFinder, jsonOutput bool) error {
+	ff.DisplayDetailedResults = true
+	results, _, _, err := getFilesFromRoots(ff)
+	if err != nil {
+		return err
+	}
+
+	stats := collectStats(results.([]types.EntryResult), time.Now())
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	if stats.TotalCount == 0 {
+		pterm.Info.Printf("%d results found matching criteria\n", stats.TotalCount)
+		return nil
+	}
+
+	renderStatsBucketsToTable("Extension", stats.Extensions, stats)
+	renderStatsBucketsToTable("File Type", stats.FileTypes, stats)
+	renderStatsBucketsToTable("File Size", stats.Sizes, stats)
+	renderStatsBucketsToTable("Modified", stats.Ages, stats)
+	return nil
+}
+
+func collectStats(entries []types.EntryResult, now time.Time) types.Stats {
+	extensions := make(map[string]*types.StatsBucket)
+	fileTypes := make(map[string]*types.StatsBucket)
+	sizes := make(map[int]*types.StatsBucket)
+	ages := make(map[string]*types.StatsBucket)
+
+	add := func(buckets map[string]*types.StatsBucket, name string, size int64) {
+		bucket, ok := buckets[name]
+		if !ok {
+			bucket = &types.StatsBucket{Name: name}
+			buckets[name] = bucket
+		}
+		bucket.Count++
+		bucket.Size += size
+	}
+
+	var stats types.Stats
+	for _, entry := range entries {
+		stats.TotalCount++
+		stats.TotalSize += entry.Size
+
+		add(extensions, extensionName(entry.FileName), entry.Size)
+		add(fileTypes, fileTypeName(entry.FileName), entry.Size)
+		add(ages, ageBucketName(now.Sub(entry.ModTime)), entry.Size)
+
+		magnitude := sizeMagnitude(entry.Size)
+		bucket, ok := sizes[magnitude]
+		if !ok {
+			bucket = &types.StatsBucket{Name: sizeBucketName(magnitude)}
+			sizes[magnitude] = bucket
+		}
+		bucket.Count++
+		bucket.Size += entry.Size
+	}
+
+	stats.Extensions = sortedBuckets(extensions)
+	stats.FileTypes = sortedBuckets(fileTypes)
+
+	magnitudes := make([]int, 0, len(sizes))
+	for magnitude := range sizes {
+		magnitudes = append(magnitudes, magnitude)
+	}
+	sort.Ints(magnitudes)
+	stats.Sizes = []types.StatsBucket{}
+	for _, magnitude := range magnitudes {
+		stats.Sizes = append(stats.Sizes, *sizes[magnitude])
+	}
+
+	stats.Ages = []types.StatsBucket{}
+	for _, bucket := range ageBuckets {
+		if ages[bucket.name] != nil {
+			stats.Ages = append(stats.Ages, *ages[bucket.name])
+		}
+	}
+	if ages["older"] != nil {
+		stats.Ages = append(stats.Ages, *ages["older"])
+	}
+
+	return stats
+}
+
+// sortedBuckets returns the buckets largest first
+func sortedBuckets(buckets map[string]*types.StatsBucket) []types.StatsBucket {
+	sorted := make([]types.StatsBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		sorted = append(sorted, *bucket)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func extensionName(fileName string) string {
+	if ext := strings.ToLower(filepath.Ext(fileName)); ext != "" {
+		return ext
+	}
+	return "(none)"
+}
+
+// fileTypeName returns the first file type category the file belongs to
+func fileTypeName(fileName string) string {
+	for _, fileType := range statsFileTypes {
+		if commonUtils.IsExtensionValid(fileType, fileName) {
+			return string(fileType)
+		}
+	}
+	return "Other"
+}
+
+func ageBucketName(age time.Duration) string {
+	for _, bucket := range ageBuckets {
+		if age <= bucket.maxAge {
+			return bucket.name
+		}
+	}
+	return "older"
+}
+
+// sizeMagnitude returns the power of ten below size, -1 for empty files
+func sizeMagnitude(size int64) int {
+	if size <= 0 {
+		return -1
+	}
+	return len(strconv.FormatInt(size, 10)) - 1
+}
+
+// sizeBucketName describes the sizes from 10^magnitude up to 10^(magnitude+1) bytes
+func sizeBucketName(magnitude int) string {
+	if magnitude < 0 {
+		return "0 B"
+	}
+	return pterm.Sprintf("%s - %s", powerOfTenSize(magnitude), powerOfTenSize(magnitude+1))
+}
+
+func powerOfTenSize(magnitude int) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+	unit := magnitude / 3
+	if unit >= len(units) {
+		unit = len(units) - 1
+	}
+	return pterm.Sprintf("%v %s", math.Pow10(magnitude-unit*3), units[unit])
+}
+
+func renderStatsBucketsToTable(title string, buckets []types.StatsBucket, stats types.Stats) {
+	t := table.Table{}
+	t.AppendHeader(table.Row{title, "Count", "Size", "Share"})
+	for _, bucket := range buckets {
+		share := 0.0
+		if stats.TotalSize > 0 {
+			share = float64(bucket.Size) / float64(stats.TotalSize)
+		}
+		t.AppendRow(table.Row{
+			bucket.Name,
+			pterm.Sprintf("%v", bucket.Count),
+			commonFormatters.FormatSize(bucket.Size),
+			usageBar(share),
+		})
+	}
+	t.AppendFooter(table.Row{"Total", pterm.Sprintf("%v", stats.TotalCount), commonFormatters.FormatSize(stats.TotalSize), ""})
+
+	renderTable(&t)
+}
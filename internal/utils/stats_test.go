@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+)
+
+// TestCollectStats checks files are counted into the extension, type, size and age buckets
+func TestCollectStats(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	entries := []types.EntryResult{
+		{FileName: "movie.MP4", Size: 2500000, ModTime: now.Add(-2 * time.Hour)},
+		{FileName: "photo.jpg", Size: 4000, ModTime: now.Add(-3 * 24 * time.Hour)},
+		{FileName: "other.jpg", Size: 9000, ModTime: now.Add(-60 * 24 * time.Hour)},
+		{FileName: "notes", Size: 0, ModTime: now.Add(-400 * 24 * time.Hour)},
+		{FileName: "script.go", Size: 7, ModTime: now.Add(-10 * 24 * time.Hour)},
+	}
+
+	stats := collectStats(entries, now)
+
+	if stats.TotalCount != 5 || stats.TotalSize != 2513007 {
+		t.Errorf("expected 5 files of 2513007 bytes, got %d files of %d bytes", stats.TotalCount, stats.TotalSize)
+	}
+
+	tests := []struct {
+		name     string
+		buckets  []types.StatsBucket
+		expected []types.StatsBucket
+	}{
+		{"extensions", stats.Extensions, []types.StatsBucket{
+			{Name: ".mp4", Count: 1, Size: 2500000},
+			{Name: ".jpg", Count: 2, Size: 13000},
+			{Name: ".go", Count: 1, Size: 7},
+			{Name: "(none)", Count: 1, Size: 0},
+		}},
+		{"file types", stats.FileTypes, []types.StatsBucket{
+			{Name: "Video", Count: 1, Size: 2500000},
+			{Name: "Image", Count: 2, Size: 13000},
+			{Name: "Other", Count: 2, Size: 7},
+		}},
+		{"sizes", stats.Sizes, []types.StatsBucket{
+			{Name: "0 B", Count: 1, Size: 0},
+			{Name: "1 B - 10 B", Count: 1, Size: 7},
+			{Name: "1 KB - 10 KB", Count: 2, Size: 13000},
+			{Name: "1 MB - 10 MB", Count: 1, Size: 2500000},
+		}},
+		{"ages", stats.Ages, []types.StatsBucket{
+			{Name: "within 1d", Count: 1, Size: 2500000},
+			{Name: "within 7d", Count: 1, Size: 4000},
+			{Name: "within 30d", Count: 1, Size: 7},
+			{Name: "within 1y", Count: 1, Size: 9000},
+			{Name: "older", Count: 1, Size: 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.buckets, tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, tt.buckets)
+		}
+	}
+}
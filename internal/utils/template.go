@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// templateExtension is the extension of the named templates in the template directory
+const templateExtension = ".tmpl"
+
+// formatUnescaper turns the escapes typed on the command line into the characters
+var formatUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n")
+
+// DefaultTemplateDirectory returns the directory named templates are loaded
+// from when --template-dir is not set
+func DefaultTemplateDirectory() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "file-finder", "templates")
+}
+
+// LoadTemplate returns the text of the --format template, with \t and \n
+// unescaped, or of the template called name in directory, and checks it can
+// be executed
+func LoadTemplate(format, name, directory string) (string, error) {
+	text := formatUnescaper.Replace(format)
+	if name != "" {
+		data, err := os.ReadFile(filepath.Join(directory, name+templateExtension))
+		if errors.Is(err, os.ErrNotExist) {
+			available := templateNames(directory)
+			if len(available) == 0 {
+				return "", fmt.Errorf("template %s not found, no templates in %s", name, directory)
+			}
+			return "", fmt.Errorf("template %s not found in %s, available: %s", name, directory, strings.Join(available, ", "))
+		}
+		if err != nil {
+			return "", err
+		}
+		// Editors add a final line break, a line is already written per result
+		text = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	}
+
+	// Executing against an empty result catches unknown fields before scanning
+	tmpl, err := parseResultTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	if err := tmpl.Execute(io.Discard, types.TemplateResult{}); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// templateNames returns the names of the templates in directory
+func templateNames(directory string) []string {
+	matches, _ := filepath.Glob(filepath.Join(directory, "*"+templateExtension))
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), templateExtension))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseResultTemplate(text string) (*template.Template, error) {
+	return template.New("format").Parse(text)
+}
+
+// renderResultsWithTemplate writes a line per result, formatted with ff.Template
+func renderResultsWithTemplate(results interface{}, ff types.FileFinder) {
+	if err := writeResultsWithTemplate(os.Stdout, results, ff); err != nil {
+		pterm.Error.Printf("error formatting results: %v\n", err)
+	}
+}
+
+func writeResultsWithTemplate(w io.Writer, results interface{}, ff types.FileFinder) error {
+	tmpl, err := parseResultTemplate(ff.Template)
+	if err != nil {
+		return err
+	}
+
+	var templateResults []types.TemplateResult
+	switch results := results.(type) {
+	case []types.EntryResult:
+		for _, entry := range results {
+			templateResults = append(templateResults, types.TemplateResult{
+				Root:      entryRoot(entry, ff.RootDirectory),
+				Directory: entry.Directory,
+				FileName:  entry.FileName,
+				Path:      entryPath(entry),
+				Extension: strings.ToLower(filepath.Ext(entry.FileName)),
+				Size:      entry.Size,
+				FileSize:  commonFormatters.FormatSize(entry.Size),
+				ModTime:   entry.ModTime,
+			})
+		}
+	case []types.DirectoryResult:
+		for _, result := range results {
+			templateResults = append(templateResults, types.TemplateResult{
+				Root:      ff.RootDirectory,
+				Directory: result.Directory,
+				Path:      result.Directory,
+				Count:     result.Count,
+			})
+		}
+	}
+
+	for _, result := range templateResults {
+		if err := tmpl.Execute(w, result); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+)
+
+// TestLoadTemplate checks --format escapes, named templates and invalid templates
+func TestLoadTemplate(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "csv.tmpl"), []byte("{{.Path}},{{.Size}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format      string
+		name        string
+		expected    string
+		expectError bool
+	}{
+		{`{{.Path}}\t{{.Size}}`, "", "{{.Path}}\t{{.Size}}", false},
+		{"", "csv", "{{.Path}},{{.Size}}", false},
+		{"", "missing", "", true},
+		{"{{.Path", "", "", true},
+		{"{{.Unknown}}", "", "", true},
+	}
+
+	for _, tt := range tests {
+		text, err := LoadTemplate(tt.format, tt.name, directory)
+		if (err != nil) != tt.expectError {
+			t.Errorf("LoadTemplate(%q, %q) error = %v, expected error %v", tt.format, tt.name, err, tt.expectError)
+			continue
+		}
+		if text != tt.expected {
+			t.Errorf("LoadTemplate(%q, %q) = %q, expected %q", tt.format, tt.name, text, tt.expected)
+		}
+	}
+}
+
+// TestWriteResultsWithTemplate checks a line is written per entry and per directory summary
+func TestWriteResultsWithTemplate(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []types.EntryResult{
+		{Root: "/r", Directory: "/r/a", FileName: "x.TXT", Size: 2048, ModTime: modTime},
+		{Root: "/r", Directory: "/r/b", FileName: "y", Size: 0, ModTime: modTime},
+	}
+
+	var sb strings.Builder
+	ff := types.FileFinder{Template: `{{.Path}}|{{.Extension}}|{{.FileSize}}|{{.ModTime.Format "2006-01-02"}}`}
+	if err := writeResultsWithTemplate(&sb, entries, ff); err != nil {
+		t.Fatal(err)
+	}
+	expected := filepath.Join("/r/a", "x.TXT") + "|.txt|2.00 KB|2024-01-02\n" + filepath.Join("/r/b", "y") + "||0 B|2024-01-02\n"
+	if sb.String() != expected {
+		t.Errorf("expected %q, got %q", expected, sb.String())
+	}
+
+	sb.Reset()
+	ff.Template = "{{.Directory}} {{.Count}}"
+	if err := writeResultsWithTemplate(&sb, []types.DirectoryResult{{Directory: "/r/a", Count: 3}}, ff); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "/r/a 3\n" {
+		t.Errorf("expected a directory summary line, got %q", sb.String())
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// IsTerminal reports whether stdout is a terminal. Output that is redirected
+// is kept free of colours, hyperlinks and paging.
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// renderTable writes t to stdout. On a terminal it is coloured, stretched to
+// the terminal width and paged when taller than the screen, otherwise it is
+// rendered as a plain text table.
+func renderTable(t *table.Table) {
+	if !IsTerminal() {
+		t.SetStyle(table.StyleDefault)
+		t.SetOutputMirror(os.Stdout)
+		t.Render()
+		return
+	}
+
+	w, h, _ := getTerminalSize()
+	t.SetStyle(table.StyleColoredDark)
+	t.Style().Size = table.SizeOptions{
+		WidthMin: w,
+	}
+	page(t.Render(), h)
+}
+
+// renderText writes output to stdout, paged on a terminal when taller than the screen
+func renderText(output string) {
+	if !IsTerminal() {
+		fmt.Println(output)
+		return
+	}
+
+	_, h, _ := getTerminalSize()
+	page(output, h)
+}
+
+// progressSpinner shows progress on a terminal. When output is redirected it
+// does not animate and only its final message is printed.
+type progressSpinner struct {
+	printer *pterm.SpinnerPrinter
+}
+
+// startSpinner starts a spinner showing text
+func startSpinner(text string) *progressSpinner {
+	if !IsTerminal() {
+		return &progressSpinner{}
+	}
+
+	printer, _ := pterm.DefaultSpinner.Start(text)
+	return &progressSpinner{printer: printer}
+}
+
+// UpdateText replaces the text shown next to the spinner
+func (s *progressSpinner) UpdateText(text string) {
+	if s.printer != nil {
+		s.printer.UpdateText(text)
+	}
+}
+
+// Success stops the spinner and prints message as a success
+func (s *progressSpinner) Success(message string) {
+	if s.printer != nil {
+		s.printer.Success(message)
+		return
+	}
+	pterm.Success.Println(message)
+}
+
+// Fail stops the spinner and prints message as an error
+func (s *progressSpinner) Fail(message string) {
+	if s.printer != nil {
+		s.printer.Fail(message)
+		return
+	}
+	pterm.Error.Println(message)
+}
+
+// Stop stops the spinner, it does nothing once the spinner finished
+func (s *progressSpinner) Stop() {
+	if s.printer != nil {
+		s.printer.Stop()
+	}
+}
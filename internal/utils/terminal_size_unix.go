@@ -13,5 +13,5 @@ func getTerminalSize() (int, int, error) {
 	if !term.IsTerminal(int(os.Stdout.Fd())) {
 		return 0, 0, fmt.Errorf("not a terminal")
 	}
-	return term.GetSize(int(os.Stdin.Fd()))
+	return term.GetSize(int(os.Stdout.Fd()))
 }
@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"container/heap"
+	"os"
+	"path/filepath"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// sizedItem is a value ranked by size in a sizeHeap
+type sizedItem struct {
+	size  int64
+	value interface{}
+}
+
+// sizeHeap is a min-heap of items by size, so the smallest is evicted first
+type sizeHeap []sizedItem
+
+func (h sizeHeap) Len() int            { return len(h) }
+func (h sizeHeap) Less(i, j int) bool  { return h[i].size < h[j].size }
+func (h sizeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sizeHeap) Push(x interface{}) { *h = append(*h, x.(sizedItem)) }
+func (h *sizeHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// topN keeps the n largest values added to it without holding on to the rest
+type topN struct {
+	n     int
+	items sizeHeap
+}
+
+func (t *topN) add(size int64, value interface{}) {
+	if len(t.items) < t.n {
+		heap.Push(&t.items, sizedItem{size: size, value: value})
+		return
+	}
+	if t.n > 0 && size > t.items[0].size {
+		t.items[0] = sizedItem{size: size, value: value}
+		heap.Fix(&t.items, 0)
+	}
+}
+
+// sorted empties the heap, returning the values largest first
+func (t *topN) sorted() []interface{} {
+	values := make([]interface{}, len(t.items))
+	for i := len(values) - 1; i >= 0; i-- {
+		values[i] = heap.Pop(&t.items).(sizedItem).value
+	}
+	return values
+}
+
+// findAndDisplayTop walks the root directories keeping only the ff.TopCount
+// largest matching files and directories, then renders them
+func findAndDisplayTop(ff types.FileFinder) ([]types.EntryResult, error) {
+	files, directories, totalCount, totalFileSize, err := findTop(ff)
+	if err != nil {
+		return nil, err
+	}
+
+	if totalCount == 0 {
+		pterm.Info.Printf("%d results found matching criteria\n", totalCount)
+		return files, nil
+	}
+
+	renderTopFilesToTable(files, totalCount, totalFileSize)
+	renderTopDirectoriesToTable(directories)
+	return files, nil
+}
+
+func findTop(ff types.FileFinder) ([]types.EntryResult, []types.UsageResult, int, int64, error) {
+	fileSize, err := convertFileSizeFilter(ff.FileSizeFilter)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	roots := ff.RootDirectories
+	if len(roots) == 0 {
+		roots = []string{ff.RootDirectory}
+	}
+
+	walker := &topWalker{
+		ff:          ff,
+		fileSize:    fileSize,
+		files:       &topN{n: ff.TopCount},
+		directories: &topN{n: ff.TopCount},
+	}
+	for _, root := range outermostRoots(roots) {
+		walker.root = root
+		if _, err := walker.walk(filepath.Clean(root)); err != nil {
+			return nil, nil, 0, 0, err
+		}
+	}
+
+	var files []types.EntryResult
+	for _, value := range walker.files.sorted() {
+		files = append(files, value.(types.EntryResult))
+	}
+	var largestDirectories []types.UsageResult
+	for _, value := range walker.directories.sorted() {
+		largestDirectories = append(largestDirectories, value.(types.UsageResult))
+	}
+
+	return files, largestDirectories, walker.totalCount, walker.totalFileSize, nil
+}
+
+// outermostRoots drops the roots inside another root, their files are already
+// found through it, so overlapping roots are only walked once
+func outermostRoots(roots []string) []string {
+	var kept, keptAbs []string
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			abs = root
+		}
+
+		nested := false
+		for i := 0; i < len(kept); i++ {
+			if isBelow(keptAbs[i], abs) {
+				nested = true
+				break
+			}
+			if isBelow(abs, keptAbs[i]) {
+				// A later root contains an earlier one, which it replaces
+				kept = append(kept[:i], kept[i+1:]...)
+				keptAbs = append(keptAbs[:i], keptAbs[i+1:]...)
+				i--
+			}
+		}
+		if !nested {
+			kept = append(kept, root)
+			keptAbs = append(keptAbs, abs)
+		}
+	}
+	return kept
+}
+
+// topWalker walks a root post-order, so a directory's size is final when its
+// walk returns and it can go straight into the heap
+type topWalker struct {
+	ff            types.FileFinder
+	fileSize      int64
+	root          string
+	files         *topN
+	directories   *topN
+	totalCount    int
+	totalFileSize int64
+}
+
+// walk returns the size, file count and largest file of the matching files
+// below dir
+func (w *topWalker) walk(dir string) (types.UsageResult, error) {
+	usage := types.UsageResult{Directory: dir}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return usage, err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if isQuarantineDirectory(path, w.ff) || isActionDirectory(path, w.ff) {
+				continue
+			}
+			// The root has to be readable, anything below it is skipped
+			subdirectory, err := w.walk(path)
+			if err != nil {
+				continue
+			}
+			addUsage(&usage, subdirectory.Size, subdirectory.FileCount, subdirectory.LargestFile, subdirectory.LargestFileSize)
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file := types.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+		if !matchesFileInfo(w.ff, file, w.fileSize) {
+			continue
+		}
+
+		w.totalCount++
+		w.totalFileSize += file.Size
+		w.files.add(file.Size, types.EntryResult{
+			Root:      w.root,
+			Directory: dir,
+			FileName:  entry.Name(),
+			FileSize:  commonFormatters.FormatSize(file.Size),
+			Size:      file.Size,
+			ModTime:   file.ModTime,
+		})
+		addUsage(&usage, file.Size, 1, path, file.Size)
+	}
+
+	if usage.FileCount > 0 && dir != filepath.Clean(w.root) {
+		w.directories.add(usage.Size, usage)
+	}
+	return usage, nil
+}
+
+// addUsage adds files to usage, keeping the largest file seen
+func addUsage(usage *types.UsageResult, size int64, fileCount int, largestFile string, largestFileSize int64) {
+	if fileCount == 0 {
+		return
+	}
+	usage.Size += size
+	usage.FileCount += fileCount
+	if usage.LargestFile == "" || largestFileSize > usage.LargestFileSize {
+		usage.LargestFile = largestFile
+		usage.LargestFileSize = largestFileSize
+	}
+}
+
+func renderTopFilesToTable(files []types.EntryResult, totalCount int, totalFileSize int64) {
+	t := table.Table{}
+	t.AppendHeader(table.Row{"#", "Largest Files", "FileSize"})
+	for i, file := range files {
+		t.AppendRow(table.Row{
+			pterm.Sprintf("%d", i+1),
+			formatResultHyperLink(entryPath(file), entryPath(file)),
+			file.FileSize,
+		})
+	}
+	t.AppendFooter(table.Row{"", pterm.Sprintf("%v files scanned", totalCount), commonFormatters.FormatSize(totalFileSize)})
+
+	renderTable(&t)
+}
+
+func renderTopDirectoriesToTable(directories []types.UsageResult) {
+	if len(directories) == 0 {
+		return
+	}
+
+	t := table.Table{}
+	t.AppendHeader(table.Row{"#", "Largest Directories", "Size", "Files", "Largest File"})
+	for i, directory := range directories {
+		t.AppendRow(table.Row{
+			pterm.Sprintf("%d", i+1),
+			formatResultHyperLink(directory.Directory, directory.Directory),
+			commonFormatters.FormatSize(directory.Size),
+			pterm.Sprintf("%v", directory.FileCount),
+			pterm.Sprintf("%s (%s)", formatResultHyperLink(directory.LargestFile, filepath.Base(directory.LargestFile)), commonFormatters.FormatSize(directory.LargestFileSize)),
+		})
+	}
+
+	renderTable(&t)
+}
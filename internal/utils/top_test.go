@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"file-finder/internal/types"
+
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestTopN checks only the largest values are kept, largest first
+func TestTopN(t *testing.T) {
+	top := &topN{n: 3}
+	for _, size := range []int64{5, 1, 9, 3, 7, 2} {
+		top.add(size, size)
+	}
+
+	sorted := top.sorted()
+	expected := []int64{9, 7, 5}
+	if len(sorted) != len(expected) {
+		t.Fatalf("expected %d values, got %v", len(expected), sorted)
+	}
+	for i, value := range sorted {
+		if value.(int64) != expected[i] {
+			t.Errorf("value %d = %v, expected %d", i, value, expected[i])
+		}
+	}
+}
+
+// TestFindTop checks the largest files and the recursive size of directories
+func TestFindTop(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"small.txt":           1,
+		"a/medium.txt":        20,
+		"a/b/large.txt":       50,
+		"c/medium-other.txt":  30,
+		"c/tiny-neighbor.txt": 2,
+	}
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, RootDirectories: []string{root}, RootDirectory: root, TopCount: 2}
+	largestFiles, largestDirectories, totalCount, totalFileSize, err := findTop(ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totalCount != 5 || totalFileSize != 103 {
+		t.Errorf("expected 5 files of 103 bytes scanned, got %d of %d bytes", totalCount, totalFileSize)
+	}
+	if len(largestFiles) != 2 || largestFiles[0].FileName != "large.txt" || largestFiles[1].FileName != "medium-other.txt" {
+		t.Errorf("unexpected largest files %+v", largestFiles)
+	}
+
+	expected := []types.UsageResult{
+		{Directory: filepath.Join(root, "a"), Size: 70, FileCount: 2, LargestFile: filepath.Join(root, "a", "b", "large.txt"), LargestFileSize: 50},
+		{Directory: filepath.Join(root, "a", "b"), Size: 50, FileCount: 1, LargestFile: filepath.Join(root, "a", "b", "large.txt"), LargestFileSize: 50},
+	}
+	if len(largestDirectories) != len(expected) {
+		t.Fatalf("expected %d directories, got %+v", len(expected), largestDirectories)
+	}
+	for i := range expected {
+		if largestDirectories[i] != expected[i] {
+			t.Errorf("directory %d = %+v, expected %+v", i, largestDirectories[i], expected[i])
+		}
+	}
+}
+
+// TestFindTopOverlappingRoots checks files under a root and its own subdirectory are counted once
+func TestFindTopOverlappingRoots(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(root, "a.txt"), filepath.Join(sub, "b.txt")} {
+		if err := os.WriteFile(path, make([]byte, 10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, RootDirectories: []string{sub, root}, RootDirectory: sub, TopCount: 5}
+	largestFiles, largestDirectories, totalCount, totalFileSize, err := findTop(ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totalCount != 2 || totalFileSize != 20 || len(largestFiles) != 2 {
+		t.Errorf("expected 2 files of 20 bytes, got %d files of %d bytes: %+v", totalCount, totalFileSize, largestFiles)
+	}
+	if len(largestDirectories) != 1 || largestDirectories[0].Directory != sub || largestDirectories[0].Size != 10 {
+		t.Errorf("expected only %s with 10 bytes, got %+v", sub, largestDirectories)
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"file-finder/internal/types"
+
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+)
+
+// treeNode is a directory of the results tree with the totals of every file below it
+type treeNode struct {
+	name        string
+	path        string
+	directories map[string]*treeNode
+	files       []types.EntryResult
+	count       int
+	size        int64
+}
+
+func newTreeNode(name, path string) *treeNode {
+	return &treeNode{name: name, path: path, directories: make(map[string]*treeNode)}
+}
+
+// renderResultsToTree renders the found files as an indented directory tree,
+// collapsing directories more than ff.TreeDepth levels below their root
+func renderResultsToTree(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder) {
+	entries, ok := results.([]types.EntryResult)
+	if !ok {
+		// Without detailed results there are no files or sizes to show
+		renderResultsToTable(results, totalCount, totalFileSize, ff)
+		return
+	}
+
+	// Written out first so the tree is paged like the tables
+	var sb strings.Builder
+	writeTree(&sb, buildResultTree(entries, ff.RootDirectory), ff.TreeDepth)
+	fmt.Fprintf(&sb, "\n%s", formatFileTotals(totalCount, totalFileSize))
+	renderText(sb.String())
+}
+
+// buildResultTree groups the entries by directory below their root, returning
+// one tree per root in the order they were first found
+func buildResultTree(entries []types.EntryResult, fallbackRoot string) []*treeNode {
+	var roots []*treeNode
+	byRoot := make(map[string]*treeNode)
+	for _, entry := range entries {
+		root := filepath.Clean(entryRoot(entry, fallbackRoot))
+		node, ok := byRoot[root]
+		if !ok {
+			node = newTreeNode(root, root)
+			byRoot[root] = node
+			roots = append(roots, node)
+		}
+
+		node.count++
+		node.size += entry.Size
+		if rel := relativeToRoot(root, filepath.Clean(entry.Directory)); rel != "." {
+			for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+				child, ok := node.directories[part]
+				if !ok {
+					child = newTreeNode(part, filepath.Join(node.path, part))
+					node.directories[part] = child
+				}
+				node = child
+				node.count++
+				node.size += entry.Size
+			}
+		}
+		node.files = append(node.files, entry)
+	}
+	return roots
+}
+
+// writeTree writes the trees the way tree does, directories before files.
+// Directories are listed by name while the files of each directory keep the
+// order of the results, so --sort-by and --reverse apply to them. A maxDepth
+// of 0 expands every directory.
+func writeTree(w io.Writer, roots []*treeNode, maxDepth int) {
+	for i, root := range roots {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %s\n", formatResultHyperLink(root.path, root.name), treeNodeTotals(root))
+		writeTreeChildren(w, root, "", 1, maxDepth)
+	}
+}
+
+func writeTreeChildren(w io.Writer, node *treeNode, prefix string, depth, maxDepth int) {
+	names := make([]string, 0, len(node.directories))
+	for name := range node.directories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	remaining := len(names) + len(node.files)
+	branch := func() (string, string) {
+		remaining--
+		if remaining == 0 {
+			return "└── ", "    "
+		}
+		return "├── ", "│   "
+	}
+
+	for _, name := range names {
+		directory := node.directories[name]
+		connector, indent := branch()
+		if maxDepth > 0 && depth >= maxDepth {
+			fmt.Fprintf(w, "%s%s%s/ … %s\n", prefix, connector, formatResultHyperLink(directory.path, directory.name), treeNodeTotals(directory))
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s/ %s\n", prefix, connector, formatResultHyperLink(directory.path, directory.name), treeNodeTotals(directory))
+		writeTreeChildren(w, directory, prefix+indent, depth+1, maxDepth)
+	}
+
+	for _, file := range node.files {
+		connector, _ := branch()
+		fmt.Fprintf(w, "%s%s%s (%s)\n", prefix, connector, formatResultHyperLink(entryPath(file), file.FileName), commonFormatters.FormatSize(file.Size))
+	}
+}
+
+func treeNodeTotals(node *treeNode) string {
+	return fmt.Sprintf("(%s)", formatFileTotals(node.count, node.size))
+}
+
+func formatFileTotals(count int, size int64) string {
+	files := "files"
+	if count == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s, %s", count, files, commonFormatters.FormatSize(size))
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestBuildResultTree checks every directory totals the files below it and deep directories collapse
+func TestBuildResultTree(t *testing.T) {
+	root := t.TempDir()
+	entries := []types.EntryResult{
+		{Root: root, Directory: root, FileName: "top.bin", Size: 5},
+		{Root: root, Directory: filepath.Join(root, "photos"), FileName: "a.jpg", Size: 10},
+		{Root: root, Directory: filepath.Join(root, "photos", "2024"), FileName: "b.jpg", Size: 30},
+		{Root: root, Directory: filepath.Join(root, "docs"), FileName: "c.pdf", Size: 20},
+	}
+
+	roots := buildResultTree(entries, "")
+	if len(roots) != 1 {
+		t.Fatalf("expected 1 root, got %d", len(roots))
+	}
+
+	photos := roots[0].directories["photos"]
+	tests := []struct {
+		name          string
+		node          *treeNode
+		expectedCount int
+		expectedSize  int64
+		expectedFiles int
+	}{
+		{"root", roots[0], 4, 65, 1},
+		{"photos", photos, 2, 40, 1},
+		{"photos/2024", photos.directories["2024"], 1, 30, 1},
+		{"docs", roots[0].directories["docs"], 1, 20, 1},
+	}
+
+	for _, tt := range tests {
+		if tt.node == nil {
+			t.Errorf("%s: missing from the tree", tt.name)
+			continue
+		}
+		if tt.node.count != tt.expectedCount || tt.node.size != tt.expectedSize || len(tt.node.files) != tt.expectedFiles {
+			t.Errorf("%s: got %d files of %d bytes with %d listed, expected %d files of %d bytes with %d listed",
+				tt.name, tt.node.count, tt.node.size, len(tt.node.files), tt.expectedCount, tt.expectedSize, tt.expectedFiles)
+		}
+	}
+
+	var sb strings.Builder
+	writeTree(&sb, roots, 1)
+	output := sb.String()
+	if strings.Count(output, "…") != 2 {
+		t.Errorf("expected docs and photos to be collapsed, got:\n%s", output)
+	}
+	if strings.Contains(output, "b.jpg") {
+		t.Errorf("expected files below the tree depth to be hidden, got:\n%s", output)
+	}
+	if !strings.Contains(output, "top.bin") {
+		t.Errorf("expected files in the root to be listed, got:\n%s", output)
+	}
+}
+
+// TestWriteTreeKeepsResultOrder checks the files of a directory are listed in
+// the order --sort-by put them in
+func TestWriteTreeKeepsResultOrder(t *testing.T) {
+	root := t.TempDir()
+	entries := []types.EntryResult{
+		{Root: root, Directory: root, FileName: "a.bin", Size: 10},
+		{Root: root, Directory: root, FileName: "b.bin", Size: 30},
+		{Root: root, Directory: root, FileName: "c.bin", Size: 20},
+	}
+	sortResults(entries, types.SortFields.Size, true)
+
+	var sb strings.Builder
+	writeTree(&sb, buildResultTree(entries, ""), 0)
+	output := sb.String()
+
+	b, c, a := strings.Index(output, "b.bin"), strings.Index(output, "c.bin"), strings.Index(output, "a.bin")
+	if b < 0 || c < 0 || a < 0 || !(b < c && c < a) {
+		t.Errorf("expected the files largest first, got:\n%s", output)
+	}
+}
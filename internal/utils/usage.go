@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"file-finder/internal/types"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+// usageBarWidth is the number of characters of a full percentage bar
+const usageBarWidth = 20
+
+// DisplayUsage renders how much space the matching files take up in each
+// directory up to depth levels below the root directories, largest first
+func DisplayUsage(ff types.FileFinder, depth int) error {
+	ff.DisplayDetailedResults = true
+	results, count, size, err := getFilesFromRoots(ff)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		pterm.Info.Printf("%d results found matching criteria\n", count)
+		return nil
+	}
+
+	usage, err := aggregateUsage(results.([]types.EntryResult), ff.RootDirectory, depth)
+	if err != nil {
+		return err
+	}
+
+	renderUsageToTable(usage, count, size)
+	return nil
+}
+
+// aggregateUsage totals the entries per directory up to depth levels below
+// their root. Like du, every directory counts the files of its whole subtree,
+// files deeper than depth count towards their ancestor at depth.
+func aggregateUsage(entries []types.EntryResult, fallbackRoot string, depth int) ([]types.UsageResult, error) {
+	byDirectory := make(map[string]*types.UsageResult)
+	for _, entry := range entries {
+		root := entryRoot(entry, fallbackRoot)
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		absDirectory, err := filepath.Abs(entry.Directory)
+		if err != nil {
+			return nil, err
+		}
+
+		var parts []string
+		if rel := relativeToRoot(absRoot, absDirectory); rel != "." && isBelow(absRoot, absDirectory) {
+			parts = strings.Split(rel, string(os.PathSeparator))
+			if len(parts) > depth {
+				parts = parts[:depth]
+			}
+		}
+
+		// The file counts towards its directory at depth and every parent up to the root
+		for i := len(parts); i >= 0; i-- {
+			directory := filepath.Join(append([]string{root}, parts[:i]...)...)
+			usage, ok := byDirectory[directory]
+			if !ok {
+				usage = &types.UsageResult{Directory: directory}
+				byDirectory[directory] = usage
+			}
+			usage.Size += entry.Size
+			usage.FileCount++
+			if usage.LargestFile == "" || entry.Size > usage.LargestFileSize {
+				usage.LargestFile = entryPath(entry)
+				usage.LargestFileSize = entry.Size
+			}
+		}
+	}
+
+	usage := make([]types.UsageResult, 0, len(byDirectory))
+	for _, result := range byDirectory {
+		usage = append(usage, *result)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Size != usage[j].Size {
+			return usage[i].Size > usage[j].Size
+		}
+		return usage[i].Directory < usage[j].Directory
+	})
+
+	return usage, nil
+}
+
+// usageBar draws share as a bar of usageBarWidth characters followed by the percentage
+func usageBar(share float64) string {
+	filled := int(share*usageBarWidth + 0.5)
+	return pterm.Sprintf("%s%s %5.1f%%", strings.Repeat("█", filled), strings.Repeat("░", usageBarWidth-filled), share*100)
+}
+
+// renderUsageToTable renders the usage with each directory's share of the
+// total, parents include their subdirectories so the shares overlap
+func renderUsageToTable(usage []types.UsageResult, totalCount int, totalSize int64) {
+	t := table.Table{}
+	t.AppendHeader(table.Row{"Directory", "Size", "Files", "Largest File", "Share"})
+	for _, result := range usage {
+		share := 0.0
+		if totalSize > 0 {
+			share = float64(result.Size) / float64(totalSize)
+		}
+		t.AppendRow(table.Row{
+			formatResultHyperLink(result.Directory, result.Directory),
+			commonFormatters.FormatSize(result.Size),
+			pterm.Sprintf("%v", result.FileCount),
+			pterm.Sprintf("%s (%s)", formatResultHyperLink(result.LargestFile, filepath.Base(result.LargestFile)), commonFormatters.FormatSize(result.LargestFileSize)),
+			usageBar(share),
+		})
+	}
+	t.AppendFooter(table.Row{
+		"Total",
+		commonFormatters.FormatSize(totalSize),
+		pterm.Sprintf("%v", totalCount),
+		"",
+		"",
+	})
+
+	renderTable(&t)
+}
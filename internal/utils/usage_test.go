@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"file-finder/internal/types"
+)
+
+// TestAggregateUsage checks files are totalled per directory at the requested
+// depth, parents including the files of their subdirectories
+func TestAggregateUsage(t *testing.T) {
+	root := t.TempDir()
+	entries := []types.EntryResult{
+		{Root: root, Directory: root, FileName: "top.bin", Size: 5},
+		{Root: root, Directory: filepath.Join(root, "photos"), FileName: "a.jpg", Size: 10},
+		{Root: root, Directory: filepath.Join(root, "photos", "2024"), FileName: "b.jpg", Size: 30},
+		{Root: root, Directory: filepath.Join(root, "docs"), FileName: "c.pdf", Size: 20},
+	}
+
+	tests := []struct {
+		depth    int
+		expected []types.UsageResult
+	}{
+		{0, []types.UsageResult{
+			{Directory: root, Size: 65, FileCount: 4, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+		}},
+		{1, []types.UsageResult{
+			{Directory: root, Size: 65, FileCount: 4, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+			{Directory: filepath.Join(root, "photos"), Size: 40, FileCount: 2, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+			{Directory: filepath.Join(root, "docs"), Size: 20, FileCount: 1, LargestFile: filepath.Join(root, "docs", "c.pdf"), LargestFileSize: 20},
+		}},
+		{2, []types.UsageResult{
+			{Directory: root, Size: 65, FileCount: 4, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+			{Directory: filepath.Join(root, "photos"), Size: 40, FileCount: 2, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+			{Directory: filepath.Join(root, "photos", "2024"), Size: 30, FileCount: 1, LargestFile: filepath.Join(root, "photos", "2024", "b.jpg"), LargestFileSize: 30},
+			{Directory: filepath.Join(root, "docs"), Size: 20, FileCount: 1, LargestFile: filepath.Join(root, "docs", "c.pdf"), LargestFileSize: 20},
+		}},
+	}
+
+	for _, tt := range tests {
+		usage, err := aggregateUsage(entries, root, tt.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(usage) != len(tt.expected) {
+			t.Errorf("depth %d: expected %d directories, got %+v", tt.depth, len(tt.expected), usage)
+			continue
+		}
+		for i := range usage {
+			if usage[i] != tt.expected[i] {
+				t.Errorf("depth %d: directory %d = %+v, expected %+v", tt.depth, i, usage[i], tt.expected[i])
+			}
+		}
+	}
+}
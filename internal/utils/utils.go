@@ -9,8 +9,10 @@ import (
 	"reflect"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"file-finder/internal/types"
 
@@ -28,29 +30,51 @@ var (
 
 // FindAndDisplayFiles gathers the results and displays them
 func FindAndDisplayFiles(ff types.FileFinder) (interface{}, error) {
-	results, count, size, err := getFiles(ff)
+	// The largest files are picked while walking so the rest is never collected
+	if ff.TopCount > 0 {
+		return findAndDisplayTop(ff)
+	}
+
+	started := time.Now()
+	results, count, size, err := getFilesFromRoots(ff)
 	if err != nil {
 		return nil, err
 	}
+	ff.ScanDuration = time.Since(started)
 
-	progressbar, _ := pterm.DefaultProgressbar.WithTotal(count).WithRemoveWhenDone(true).Start()
-
-	if !ff.DisplayDetailedResults {
-		ff.Results = results.(map[string][]string)
+	// The progress bar redraws itself with escape sequences, so it is only
+	// shown on a terminal
+	if IsTerminal() {
+		progressbar, _ := pterm.DefaultProgressbar.WithTotal(count).WithRemoveWhenDone(true).Start()
 		for i := 0; i < count; i++ {
 			progressbar.Increment()
 		}
+		progressbar.Stop()
+	}
+
+	if !ff.DisplayDetailedResults {
+		ff.Results = results.(map[string][]string)
 		results = processResults(ff.Results)
-	} else {
-		for i := 0; i < count; i++ {
-			progressbar.Increment()
-		}
 	}
 
-	progressbar.Stop()
+	if ff.SimilarImages {
+		return findAndDisplaySimilarImages(results.([]types.EntryResult), ff), nil
+	}
+
+	if ff.SimilarNames {
+		return findAndDisplaySimilarNames(results.([]types.EntryResult), ff), nil
+	}
+
+	if ff.UniqueToRoot != "" {
+		return findAndDisplayUniqueFiles(results.([]types.EntryResult), ff), nil
+	}
+
+	if ff.ListDuplicateFiles {
+		return findAndDisplayDuplicates(results.([]types.EntryResult), ff), nil
+	}
 
 	if count > 0 {
-		renderResultsToTable(results, count, size, ff)
+		renderResults(results, count, size, ff)
 	} else {
 		pterm.Info.Printf("%d results found matching criteria\n", count)
 	}
@@ -67,6 +91,96 @@ func getResultsCount(results interface{}) (int, error) {
 	return val.Len(), nil
 }
 
+// getFilesFromRoots runs getFiles for every root directory and merges the
+// results, tagging detailed entries with the root they were found under. Files
+// reachable from more than one root, because roots overlap, are only kept once
+// and tagged with the innermost root.
+func getFilesFromRoots(ff types.FileFinder) (interface{}, int, int64, error) {
+	roots := ff.RootDirectories
+	if len(roots) == 0 {
+		roots = []string{ff.RootDirectory}
+	}
+
+	seen := make(map[string]bool)
+	isNew := func(path string) bool {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if seen[path] {
+			return false
+		}
+		seen[path] = true
+		return true
+	}
+
+	detailedResults := []types.EntryResult{}
+	results := make(map[string][]string)
+	var totalCount int
+	var totalFileSize int64
+	for _, root := range roots {
+		rootFF := ff
+		rootFF.RootDirectory = root
+
+		getRootFiles := getFiles
+		if ff.UseIndex {
+			getRootFiles = getFilesFromIndex
+		}
+		rootResults, _, _, err := getRootFiles(rootFF)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		switch v := rootResults.(type) {
+		case []types.EntryResult:
+			for _, entry := range v {
+				if !isNew(entryPath(entry)) {
+					continue
+				}
+				entry.Root = innermostRoot(entryPath(entry), roots, root)
+				detailedResults = append(detailedResults, entry)
+				totalFileSize += entry.Size
+				totalCount++
+			}
+		case map[string][]string:
+			for dir, files := range v {
+				for _, file := range files {
+					if !isNew(file) {
+						continue
+					}
+					results[dir] = append(results[dir], file)
+					totalCount++
+				}
+			}
+		}
+	}
+
+	if ff.DisplayDetailedResults {
+		return detailedResults, totalCount, totalFileSize, nil
+	}
+	return results, totalCount, 0, nil
+}
+
+// innermostRoot returns the deepest of roots containing path, or fallback when
+// none does
+func innermostRoot(path string, roots []string, fallback string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fallback
+	}
+
+	innermost, depth := fallback, -1
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil || !isBelow(absRoot, absPath) {
+			continue
+		}
+		if d := strings.Count(absRoot, string(os.PathSeparator)); d > depth {
+			innermost, depth = root, d
+		}
+	}
+	return innermost
+}
+
 // getFiles handles getting the files based on the criteria
 func getFiles(ff types.FileFinder) (interface{}, int, int64, error) {
 	entries, err := os.ReadDir(ff.RootDirectory)
@@ -91,16 +205,20 @@ func getFiles(ff types.FileFinder) (interface{}, int, int64, error) {
 	// Process each directory entry
 	semaphore = make(chan struct{}, runtime.NumCPU())
 	for _, entry := range entries {
+		path := filepath.Join(ff.RootDirectory, entry.Name())
+		if entry.IsDir() && (isQuarantineDirectory(path, ff) || isActionDirectory(path, ff)) {
+			continue
+		}
+
 		wg.Add(1)
-		go func(entry os.DirEntry) {
+		go func(entry os.DirEntry, path string) {
 			defer wg.Done()
-			path := filepath.Join(ff.RootDirectory, entry.Name())
 			if entry.IsDir() {
 				processDirectory(path, ff, &detailedResults, &results, &totalCount, &totalFileSize, &mu, semaphore)
 			} else {
 				processFile(entry, path, ff, fileSize, &detailedResults, &results, &totalCount, &totalFileSize, &mu)
 			}
-		}(entry)
+		}(entry, path)
 	}
 
 	wg.Wait()
@@ -119,6 +237,33 @@ func convertFileSizeFilter(fileSizeFilter string) (int64, error) {
 	return commonUtils.ConvertStringSizeToBytes(fileSizeFilter)
 }
 
+// ParseAge parses a duration such as "30d", "2w" or "36h". On top of the units
+// understood by time.ParseDuration it accepts whole days (d) and weeks (w)
+func ParseAge(age string) (time.Duration, error) {
+	age = strings.TrimSpace(age)
+	if age == "" {
+		return 0, errors.New("age cannot be empty")
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[age[len(age)-1]]; ok {
+		value, err := strconv.Atoi(age[:len(age)-1])
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid age: %s", age)
+		}
+		return time.Duration(value) * unit, nil
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age: %s", age)
+	}
+	return d, nil
+}
+
 func processDirectory(path string, ff types.FileFinder, detailedResults *[]types.EntryResult, results *map[string][]string, totalCount *int, totalFileSize *int64, mu *sync.Mutex, semaphore chan struct{}) {
 	semaphore <- struct{}{}        // Acquire semaphore
 	defer func() { <-semaphore }() // Release semaphore
@@ -167,6 +312,11 @@ func processFile(entry os.DirEntry, path string, ff types.FileFinder, fileSize i
 		return
 	}
 
+	// Apply modification date filters if necessary
+	if !applyModTimeFilter(ff, info.ModTime()) {
+		return
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
 
@@ -175,6 +325,8 @@ func processFile(entry os.DirEntry, path string, ff types.FileFinder, fileSize i
 			Directory: ff.RootDirectory,
 			FileName:  entry.Name(),
 			FileSize:  commonFormatters.FormatSize(size),
+			Size:      size,
+			ModTime:   info.ModTime(),
 		})
 		*totalFileSize += size
 	} else {
@@ -213,6 +365,17 @@ func applyFileNameFilter(ff types.FileFinder, fileName string) bool {
 	return strings.Contains(lowerEntryName, lowerFileNameFilter)
 }
 
+// applyModTimeFilter checks if a file matches the modification date criteria
+func applyModTimeFilter(ff types.FileFinder, modTime time.Time) bool {
+	if !ff.ModifiedAfter.IsZero() && modTime.Before(ff.ModifiedAfter) {
+		return false
+	}
+	if !ff.ModifiedBefore.IsZero() && modTime.After(ff.ModifiedBefore) {
+		return false
+	}
+	return true
+}
+
 func deleteEntryResults(entries []types.EntryResult) (int, []string) {
 	var deletedCount int
 	var directoriesToRemove []string
@@ -264,7 +427,7 @@ func deleteEntryResults(entries []types.EntryResult) (int, []string) {
 // }
 
 func deleteFileBasedOnResults(results interface{}) error {
-	spinner, _ := pterm.DefaultSpinner.Start("Deleting files and directories...")
+	spinner := startSpinner("Deleting files and directories...")
 	defer spinner.Stop()
 
 	var deletedFileCount int
@@ -273,11 +436,14 @@ func deleteFileBasedOnResults(results interface{}) error {
 	switch v := results.(type) {
 	case []types.EntryResult:
 		deletedFileCount, directoriesToRemove = deleteEntryResults(v)
+	case []types.DuplicateResult, []types.SimilarImageResult, []types.SimilarNameResult:
+		entries, _ := toEntryResults(v)
+		deletedFileCount, directoriesToRemove = deleteEntryResults(entries)
 	// Part of the bug related to the func above
 	// case []types.DirectoryResult:
 	// 		deletedFileCount, directoriesToRemove = deleteDirectoryResults(v)
 	default:
-		return fmt.Errorf("invalid data format: expected []EntryResults or []DirectoryResult, got %T", results)
+		return fmt.Errorf("invalid data format: expected []EntryResults or []DuplicateResult, got %T", results)
 	}
 
 	// Sort and filter directories
@@ -393,6 +559,11 @@ func processResults(results map[string][]string) []types.DirectoryResult {
 }
 
 func formatResultHyperLink(link, txt string) string {
+	// Redirected output gets the plain text, without colours or escape sequences
+	if !IsTerminal() {
+		return txt
+	}
+
 	text.EnableColors()
 
 	link = commonFormatters.FormatPath(link, runtime.GOOS)
@@ -425,13 +596,19 @@ func formatResultHyperLink(link, txt string) string {
 // }
 
 func renderResultsToTable(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder) {
-	t := table.Table{}
-	w, _, err := getTerminalSize()
-	if err != nil {
-		fmt.Printf("error getting terminal size %v\n", err)
+	t, ok := buildResultsTable(results, totalCount, totalFileSize, ff, formatResultHyperLink)
+	if !ok {
+		return
 	}
-	// fmt.Printf("Terminal size: Width = %d, Height = %d\n", w, h)
+	renderTable(&t)
+}
+
+// buildResultsTable fills the results table, formatLink renders the directory
+// and file cells so other renderers can leave out the terminal hyperlinks
+func buildResultsTable(results interface{}, totalCount int, totalFileSize int64, ff types.FileFinder, formatLink func(link, txt string) string) (table.Table, bool) {
+	t := table.Table{}
 	// Determine header and footer based on the type of results
+	showRoot := len(ff.RootDirectories) > 1
 	var header table.Row
 	var footer table.Row
 	switch results.(type) {
@@ -441,8 +618,16 @@ func renderResultsToTable(results interface{}, totalCount int, totalFileSize int
 	case []types.EntryResult:
 		header = table.Row{"Directory", "FileName", "FileSize"}
 		footer = table.Row{"Total", pterm.Sprintf("%v", totalCount), pterm.Sprintf("%v", commonFormatters.FormatSize(totalFileSize))}
+		if showRoot {
+			header = append(table.Row{"Root"}, header...)
+			footer = append(table.Row{"Total", ""}, footer[1:]...)
+		}
+		if ff.GroupBy != "" {
+			header = append(table.Row{"Group"}, header...)
+			footer = append(table.Row{"Total", ""}, footer[1:]...)
+		}
 	default:
-		return // Exit if results type is not supported
+		return t, false // Exit if results type is not supported
 	}
 
 	t.AppendHeader(header)
@@ -452,29 +637,42 @@ func renderResultsToTable(results interface{}, totalCount int, totalFileSize int
 	case []types.DirectoryResult:
 		for _, result := range results {
 			t.AppendRow(table.Row{
-				formatResultHyperLink(result.Directory, result.Directory),
+				formatLink(result.Directory, result.Directory),
 				pterm.Sprintf("%v", result.Count),
 			})
 		}
 	case []types.EntryResult:
 		if ff.DisplayDetailedResults {
-			for _, result := range results {
-				newLink := pterm.Sprintf("%s/%s", result.Directory, result.FileName)
-				t.AppendRow(table.Row{
-					formatResultHyperLink(result.Directory, result.Directory),
-					formatResultHyperLink(newLink, result.FileName),
-					result.FileSize,
-				})
+			groups := []entryGroup{{entries: results}}
+			if ff.GroupBy != "" {
+				groups = groupEntries(results, ff.GroupBy)
+				// Every row repeats its group, merged into a single cell
+				t.SetColumnConfigs([]table.ColumnConfig{{Number: 1, AutoMerge: true}})
+			}
+			for i, group := range groups {
+				if i > 0 {
+					t.AppendSeparator()
+				}
+				for _, result := range group.entries {
+					newLink := pterm.Sprintf("%s/%s", result.Directory, result.FileName)
+					row := table.Row{
+						formatLink(result.Directory, result.Directory),
+						formatLink(newLink, result.FileName),
+						result.FileSize,
+					}
+					if showRoot {
+						row = append(table.Row{result.Root}, row...)
+					}
+					if ff.GroupBy != "" {
+						row = append(table.Row{pterm.Sprintf("%s (%s)", group.name, formatFileTotals(len(group.entries), group.size))}, row...)
+					}
+					t.AppendRow(row)
+				}
 			}
 		}
 	}
 
 	t.AppendFooter(footer)
 
-	t.SetStyle(table.StyleColoredDark)
-	t.Style().Size = table.SizeOptions{
-		WidthMin: w,
-	}
-	t.SetOutputMirror(os.Stdout)
-	t.Render()
+	return t, true
 }
@@ -0,0 +1,458 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/fsnotify/fsnotify"
+	commonFormatters "github.com/ondrovic/common/utils/formatters"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// watchSettleDelay is how long a file has to stay unchanged before it is
+	// matched, so actions never run on a file that is still being written
+	watchSettleDelay = 500 * time.Millisecond
+	// watchTickInterval is how often settled files are checked
+	watchTickInterval = 100 * time.Millisecond
+)
+
+// fileWatcher tracks which files under the watched roots match the filters
+type fileWatcher struct {
+	ff       types.FileFinder
+	fileSize int64
+	watcher  *fsnotify.Watcher
+	// roots maps each watched directory to the root directory it is under
+	roots map[string]string
+	// matched holds the files currently matching the filters by path
+	matched map[string]types.EntryResult
+	// pending holds changed files waiting to settle with the time of their last change
+	pending map[string]time.Time
+	// ignored lists absolute directories whose changes are never reported
+	ignored []string
+	// moved holds the files the watcher moved away itself, their removal is not reported
+	moved map[string]bool
+	// newerThan and olderThan are the ages given to --newer-than and
+	// --older-than, the cutoffs are worked out again every time a file is checked
+	newerThan time.Duration
+	olderThan time.Duration
+	// agings holds the files that start or stop matching once they are older,
+	// with the time their age crosses a cutoff
+	agings map[string]time.Time
+	// commands runs --exec in the background, finished receives the events
+	// of matches whose command completed
+	commands *commandLimiter
+	finished chan types.WatchEvent
+	emit     func(types.WatchEvent)
+	warning  *pterm.PrefixPrinter
+}
+
+// WatchFiles scans the root directories, then reports every file that starts
+// or stops matching the filters until interrupted, including files whose age
+// crosses the newerThan or olderThan cutoff. New matches get the configured
+// action or command run on them.
+func WatchFiles(ff types.FileFinder, newerThan, olderThan time.Duration, jsonOutput bool) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	emit := printWatchEvent
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		emit = func(event types.WatchEvent) {
+			encoder.Encode(event)
+		}
+	}
+
+	w, err := newFileWatcher(ff, watcher, emit)
+	if err != nil {
+		return err
+	}
+	w.newerThan, w.olderThan = newerThan, olderThan
+	if jsonOutput {
+		// Keep stdout to the JSON events
+		w.warning = pterm.Warning.WithWriter(os.Stderr)
+	}
+
+	var totalFileSize int64
+	for _, root := range ff.RootDirectories {
+		if err := w.addDirectory(root, root, false); err != nil {
+			return err
+		}
+	}
+	for _, entry := range w.matched {
+		totalFileSize += entry.Size
+	}
+	if !jsonOutput {
+		pterm.Info.Printf("Watching %d matching files (%s), press Ctrl+C to stop\n", len(w.matched), commonFormatters.FormatSize(totalFileSize))
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(watchTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.warning.Printf("watch error: %v\n", err)
+		case event := <-w.finished:
+			w.emit(event)
+		case now := <-ticker.C:
+			w.updateSettled(now)
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func newFileWatcher(ff types.FileFinder, watcher *fsnotify.Watcher, emit func(types.WatchEvent)) (*fileWatcher, error) {
+	fileSize, err := convertFileSizeFilter(ff.FileSizeFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &fileWatcher{
+		ff:       ff,
+		fileSize: fileSize,
+		watcher:  watcher,
+		roots:    make(map[string]string),
+		matched:  make(map[string]types.EntryResult),
+		pending:  make(map[string]time.Time),
+		moved:    make(map[string]bool),
+		agings:   make(map[string]time.Time),
+		commands: newCommandLimiter(ff.ExecJobs),
+		finished: make(chan types.WatchEvent),
+		emit:     emit,
+		warning:  &pterm.Warning,
+	}
+
+	// Files copied or moved into a watched directory would match again
+	if ff.ActionDirectory != "" {
+		absTarget, err := filepath.Abs(ff.ActionDirectory)
+		if err != nil {
+			return nil, err
+		}
+		w.ignored = append(w.ignored, absTarget)
+	}
+
+	return w, nil
+}
+
+// addDirectory watches dir and everything below it. Files found are matched
+// straight away during the initial scan and once settled otherwise.
+func (w *fileWatcher) addDirectory(root, dir string, report bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The root has to be readable, anything below it may disappear or be unreadable
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+
+		if !d.IsDir() {
+			if report {
+				w.pending[path] = time.Now()
+			} else {
+				w.update(root, path, false)
+			}
+			return nil
+		}
+
+		if w.isIgnored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			if path == dir {
+				return err
+			}
+			w.warning.Printf("unable to watch %s: %v\n", path, err)
+			return filepath.SkipDir
+		}
+		w.roots[path] = root
+		return nil
+	})
+}
+
+func (w *fileWatcher) handleEvent(event fsnotify.Event) {
+	path := event.Name
+	if w.isIgnored(path) {
+		return
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if w.moved[path] {
+			delete(w.moved, path)
+			delete(w.pending, path)
+			return
+		}
+		w.remove(path)
+		return
+	}
+
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) && !event.Has(fsnotify.Chmod) {
+		return
+	}
+	// A new file took the place of one moved away
+	delete(w.moved, path)
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		if _, watched := w.roots[path]; watched {
+			return
+		}
+		root, ok := w.roots[filepath.Dir(path)]
+		if !ok {
+			return
+		}
+		if err := w.addDirectory(root, path, true); err != nil {
+			w.warning.Printf("unable to watch %s: %v\n", path, err)
+		}
+		return
+	}
+
+	w.pending[path] = time.Now()
+}
+
+// updateSettled matches the pending files that have not changed for
+// watchSettleDelay and the files whose age crossed a cutoff
+func (w *fileWatcher) updateSettled(now time.Time) {
+	for path, crossed := range w.agings {
+		if !now.After(crossed) {
+			continue
+		}
+		delete(w.agings, path)
+
+		if root, ok := w.roots[filepath.Dir(path)]; ok {
+			w.update(root, path, true)
+		}
+	}
+
+	for path, changed := range w.pending {
+		if now.Sub(changed) < watchSettleDelay {
+			continue
+		}
+		delete(w.pending, path)
+
+		if root, ok := w.roots[filepath.Dir(path)]; ok {
+			w.update(root, path, true)
+		}
+	}
+}
+
+// update checks path against the filters, reporting when that changed
+func (w *fileWatcher) update(root, path string, report bool) {
+	info, err := os.Lstat(path)
+	if err != nil || info.IsDir() {
+		w.remove(path)
+		return
+	}
+
+	entry := types.EntryResult{
+		Root:      root,
+		Directory: filepath.Dir(path),
+		FileName:  filepath.Base(path),
+		FileSize:  commonFormatters.FormatSize(info.Size()),
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+	}
+	now := time.Now()
+	matches := matchesFileInfo(w.filtersAt(now), types.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()}, w.fileSize)
+	w.scheduleAging(path, info.ModTime(), now)
+
+	_, wasMatched := w.matched[path]
+	switch {
+	case matches && !wasMatched:
+		w.matched[path] = entry
+		if report {
+			w.runAction(entry)
+		}
+	case matches:
+		w.matched[path] = entry
+	case wasMatched:
+		delete(w.matched, path)
+		if report {
+			w.emit(watchEvent(types.WatchEventTypes.Unmatched, entry))
+		}
+	}
+}
+
+// filtersAt returns the filters with the --newer-than and --older-than
+// cutoffs worked out relative to now
+func (w *fileWatcher) filtersAt(now time.Time) types.FileFinder {
+	ff := w.ff
+	if w.newerThan > 0 {
+		ff.ModifiedAfter = now.Add(-w.newerThan)
+	}
+	if w.olderThan > 0 {
+		ff.ModifiedBefore = now.Add(-w.olderThan)
+	}
+	return ff
+}
+
+// scheduleAging remembers when the age of the file modified at modTime next
+// crosses the --newer-than or --older-than cutoff, as it may start or stop
+// matching then without changing
+func (w *fileWatcher) scheduleAging(path string, modTime, now time.Time) {
+	delete(w.agings, path)
+	for _, age := range []time.Duration{w.newerThan, w.olderThan} {
+		crossed := modTime.Add(age)
+		if age <= 0 || !crossed.After(now) {
+			continue
+		}
+		if next, ok := w.agings[path]; !ok || crossed.Before(next) {
+			w.agings[path] = crossed
+		}
+	}
+}
+
+// remove forgets path, and everything below it when it was a directory
+func (w *fileWatcher) remove(path string) {
+	delete(w.pending, path)
+	delete(w.agings, path)
+
+	if entry, ok := w.matched[path]; ok {
+		delete(w.matched, path)
+		w.emit(watchEvent(types.WatchEventTypes.Unmatched, entry))
+	}
+
+	if _, ok := w.roots[path]; !ok {
+		return
+	}
+	for dir := range w.roots {
+		if isBelow(path, dir) {
+			delete(w.roots, dir)
+		}
+	}
+	for matchedPath, entry := range w.matched {
+		if isBelow(path, matchedPath) {
+			delete(w.matched, matchedPath)
+			w.emit(watchEvent(types.WatchEventTypes.Unmatched, entry))
+		}
+	}
+	for pendingPath := range w.pending {
+		if isBelow(path, pendingPath) {
+			delete(w.pending, pendingPath)
+		}
+	}
+}
+
+// runAction reports a newly matched file after applying the configured
+// action to it. Commands run in the background so a slow one does not hold up
+// other changes, the file is reported once its command completed.
+func (w *fileWatcher) runAction(entry types.EntryResult) {
+	event := watchEvent(types.WatchEventTypes.Matched, entry)
+
+	switch {
+	case w.ff.ActionType != "":
+		event.Outcome, event.Message = w.applyAction(entry)
+
+	case w.ff.ExecCommand != "":
+		commands, err := buildCommands(w.ff.ExecCommand, []string{entryPath(entry)})
+		if err != nil {
+			event.Outcome, event.Message = types.ActionOutcomes.Failed, err.Error()
+			break
+		}
+		go func() {
+			result := w.commands.run(commands[0])
+			if result.ExitCode != 0 {
+				event.Outcome, event.Message = types.ActionOutcomes.Failed, fmt.Sprintf("exit code %d: %s", result.ExitCode, result.Message)
+			} else {
+				event.Outcome, event.Message = types.ActionOutcomes.Done, result.Command
+			}
+			w.finished <- event
+		}()
+		return
+	}
+
+	w.emit(event)
+}
+
+// applyAction moves, copies or links a newly matched file
+func (w *fileWatcher) applyAction(entry types.EntryResult) (types.ActionOutcome, string) {
+	actionResults, err := applyActionToEntries([]types.EntryResult{entry}, w.ff)
+	if err != nil {
+		return types.ActionOutcomes.Failed, err.Error()
+	}
+	result := actionResults[0]
+	if result.Outcome != types.ActionOutcomes.Done {
+		return result.Outcome, result.Message
+	}
+
+	if w.ff.ActionType == types.ActionTypes.Move {
+		// The file left the root, which is no change to the files matching
+		path := entryPath(entry)
+		delete(w.matched, path)
+		w.moved[path] = true
+	}
+	return result.Outcome, result.Destination
+}
+
+// isIgnored reports whether path is in a directory whose changes are not reported
+func (w *fileWatcher) isIgnored(path string) bool {
+	if isQuarantineDirectory(path, w.ff) {
+		return true
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range w.ignored {
+		if isBelow(dir, absPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func watchEvent(eventType types.WatchEventType, entry types.EntryResult) types.WatchEvent {
+	return types.WatchEvent{
+		Event:   eventType,
+		Path:    entryPath(entry),
+		Size:    entry.Size,
+		ModTime: entry.ModTime,
+	}
+}
+
+func printWatchEvent(event types.WatchEvent) {
+	switch event.Event {
+	case types.WatchEventTypes.Matched:
+		pterm.Success.Printf("Matched %s (%s)\n", formatResultHyperLink(event.Path, event.Path), commonFormatters.FormatSize(event.Size))
+	case types.WatchEventTypes.Unmatched:
+		pterm.Info.Printf("No longer matching %s\n", event.Path)
+	}
+
+	switch event.Outcome {
+	case types.ActionOutcomes.Done:
+		pterm.Info.Printf("  %s\n", event.Message)
+	case types.ActionOutcomes.Skipped:
+		pterm.Warning.Printf("  skipped: %s\n", event.Message)
+	case types.ActionOutcomes.Failed:
+		pterm.Error.Printf("  failed: %s\n", event.Message)
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-finder/internal/types"
+
+	"github.com/fsnotify/fsnotify"
+	commonTypes "github.com/ondrovic/common/types"
+)
+
+// TestFileWatcherTracksMatches checks files are reported once when they start
+// matching and again when they stop matching or disappear
+func TestFileWatcherTracksMatches(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "report-old.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	var events []types.WatchEvent
+	ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, FileNameFilter: "report", RootDirectories: []string{root}, RootDirectory: root}
+	w, err := newFileWatcher(ff, watcher, func(event types.WatchEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.addDirectory(root, root, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 || len(w.matched) != 1 {
+		t.Fatalf("expected the initial scan to match silently, got %d events and %d matches", len(events), len(w.matched))
+	}
+
+	subDirectory := filepath.Join(root, "sub")
+	if err := os.Mkdir(subDirectory, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	created := filepath.Join(subDirectory, "report-new.txt")
+	if err := os.WriteFile(created, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(subDirectory, "notes.txt"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w.handleEvent(fsnotify.Event{Name: subDirectory, Op: fsnotify.Create})
+	w.updateSettled(time.Now().Add(watchSettleDelay))
+	w.update(root, created, true)
+	w.remove(existing)
+
+	expected := []types.WatchEvent{
+		{Event: types.WatchEventTypes.Matched, Path: created},
+		{Event: types.WatchEventTypes.Unmatched, Path: existing},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Event != expected[i].Event || event.Path != expected[i].Path {
+			t.Errorf("event %d = %s %s, expected %s %s", i, event.Event, event.Path, expected[i].Event, expected[i].Path)
+		}
+	}
+
+	if root, ok := w.roots[subDirectory]; !ok || root != ff.RootDirectory {
+		t.Errorf("expected the new directory to be watched under %s", ff.RootDirectory)
+	}
+}
+
+// TestFileWatcherMoveAction checks a file moved away by --move-to is reported
+// once, not again when its removal from the root is seen
+func TestFileWatcherMoveAction(t *testing.T) {
+	root := t.TempDir()
+	target := t.TempDir()
+	path := filepath.Join(root, "report.txt")
+	if err := os.WriteFile(path, []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	var events []types.WatchEvent
+	ff := types.FileFinder{
+		ActionDirectory:   target,
+		ActionType:        types.ActionTypes.Move,
+		CollisionStrategy: types.CollisionStrategies.Skip,
+		FileTypeFilter:    commonTypes.FileTypes.Any,
+		RootDirectories:   []string{root},
+		RootDirectory:     root,
+	}
+	w, err := newFileWatcher(ff, watcher, func(event types.WatchEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.roots[root] = root
+
+	w.update(root, path, true)
+	w.handleEvent(fsnotify.Event{Name: path, Op: fsnotify.Rename})
+
+	if len(events) != 1 || events[0].Event != types.WatchEventTypes.Matched || events[0].Outcome != types.ActionOutcomes.Done {
+		t.Fatalf("expected a single matched and moved event, got %+v", events)
+	}
+	if _, err := os.Stat(filepath.Join(target, "report.txt")); err != nil {
+		t.Errorf("expected the file to be moved: %v", err)
+	}
+}
+
+// TestFileWatcherAging checks files are reported when their age crosses the
+// --newer-than or --older-than cutoff without them changing
+func TestFileWatcherAging(t *testing.T) {
+	tests := []struct {
+		name      string
+		newerThan time.Duration
+		olderThan time.Duration
+		initially bool
+		expected  types.WatchEventType
+	}{
+		{"newer than", time.Hour, 0, true, types.WatchEventTypes.Unmatched},
+		{"older than", 0, time.Hour, false, types.WatchEventTypes.Matched},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, "file.txt")
+			if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			// Crosses the one hour cutoff shortly after the initial scan
+			modTime := time.Now().Add(-time.Hour + 100*time.Millisecond)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer watcher.Close()
+
+			var events []types.WatchEvent
+			ff := types.FileFinder{FileTypeFilter: commonTypes.FileTypes.Any, RootDirectories: []string{root}, RootDirectory: root}
+			w, err := newFileWatcher(ff, watcher, func(event types.WatchEvent) {
+				events = append(events, event)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.newerThan, w.olderThan = tt.newerThan, tt.olderThan
+
+			if err := w.addDirectory(root, root, false); err != nil {
+				t.Fatal(err)
+			}
+			if _, matched := w.matched[path]; matched != tt.initially {
+				t.Fatalf("expected the initial scan to match %v, got %v", tt.initially, matched)
+			}
+
+			time.Sleep(200 * time.Millisecond)
+			w.updateSettled(time.Now())
+			if len(events) != 1 || events[0].Event != tt.expected {
+				t.Errorf("expected one %s event, got %+v", tt.expected, events)
+			}
+		})
+	}
+}